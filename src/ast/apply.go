@@ -0,0 +1,405 @@
+package ast
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// An ApplyFunc is invoked by Apply for each node n before and/or after
+// the node's children, using a Cursor describing the current node and
+// providing operations on it.
+//
+// The return value of ApplyFunc controls the syntax tree traversal.
+// See Apply for details.
+type ApplyFunc func(*Cursor) bool
+
+// Apply traverses a syntax tree recursively, starting with root, and
+// calling pre and post for each node:
+//
+//   - If pre is not nil, it is called for each node before the node's
+//     children are traversed (pre-order). If pre returns false, no
+//     children are traversed, and post is not called for that node.
+//   - If post is not nil, and a prior call of pre didn't return false,
+//     post is called for each node after its children are traversed
+//     (post-order). If post returns false, traversal is terminated and
+//     Apply returns immediately.
+//
+// Only fields that refer to AST nodes are considered children, nil
+// children are skipped, and children are traversed in the order of the
+// node's fields, like Walk. Nodes inserted through the Cursor are not
+// traversed, while a node put in place with Replace during pre is. A node
+// that pre deletes or replaces by nil gets neither its children traversed
+// nor post called.
+//
+// Apply returns the root node, which differs from root if pre or post
+// replaced it.
+func Apply(root Node, pre, post ApplyFunc) (result Node) {
+	parent := &struct{ Node }{root}
+
+	defer func() {
+		if r := recover(); r != nil && r != abort {
+			panic(r)
+		}
+
+		result = parent.Node
+	}()
+
+	if root != nil {
+		application := &application{pre: pre, post: post}
+		application.apply(nil, "", reflect.ValueOf(parent).Elem().Field(0), nil, root)
+	}
+
+	return
+}
+
+// Modify calls modifier on every node in the tree rooted at node, children
+// first, and puts the node it returns in place of the original one. A nil
+// result deletes the node when it is an element of a list, such as a
+// statement of a block. Modify returns the new root.
+func Modify(node Node, modifier func(Node) Node) Node {
+	return Apply(node, nil, func(cursor *Cursor) bool {
+		modified := modifier(cursor.Node())
+
+		if modified == nil && cursor.Index() >= 0 {
+			cursor.Delete()
+		} else if modified != cursor.Node() {
+			cursor.Replace(modified)
+		}
+
+		return true
+	})
+}
+
+var abort = new(int) // singleton, to signal termination of Apply
+
+// A Cursor describes a node encountered during Apply. Information about
+// the node and its parent is available from the Node, Parent, Name, and
+// Index methods.
+//
+// The methods Replace, Delete, InsertBefore, and InsertAfter can be used
+// to change the AST without disrupting Apply.
+type Cursor struct {
+	parent Node
+	name   string
+	field  reflect.Value // the parent's field holding the node
+	iter   *iterator     // valid if the field is a list
+	node   Node
+}
+
+type iterator struct {
+	index, step int
+}
+
+// Node returns the current Node.
+func (cursor *Cursor) Node() Node { return cursor.node }
+
+// Parent returns the parent of the current Node, or nil for the root.
+func (cursor *Cursor) Parent() Node { return cursor.parent }
+
+// Name returns the name of the parent Node field that contains the current
+// Node. For the keys and values of hash literals and hash patterns it is
+// "Key" or "Value".
+func (cursor *Cursor) Name() string { return cursor.name }
+
+// Index reports the index >= 0 of the current Node in the list of Nodes
+// that contains it, or a value < 0 if the current Node is not part of a
+// list. The index of the current node changes if InsertBefore is called
+// while processing the current node.
+func (cursor *Cursor) Index() int {
+	if cursor.iter != nil {
+		return cursor.iter.index
+	}
+
+	return -1
+}
+
+// Replace replaces the current Node with node. The replacement node is
+// not walked by Apply, unless Replace is called from pre.
+func (cursor *Cursor) Replace(node Node) {
+	value := cursor.field
+
+	if cursor.iter != nil {
+		value = value.Index(cursor.iter.index)
+	}
+
+	if node == nil {
+		value.Set(reflect.Zero(value.Type()))
+	} else {
+		value.Set(reflect.ValueOf(node))
+	}
+
+	cursor.node = node
+}
+
+// Delete deletes the current Node from its containing list. If the current
+// Node is not part of a list, Delete panics.
+func (cursor *Cursor) Delete() {
+	index := cursor.listIndex("Delete")
+	length := cursor.field.Len()
+
+	reflect.Copy(cursor.field.Slice(index, length), cursor.field.Slice(index+1, length))
+	cursor.field.Index(length - 1).Set(reflect.Zero(cursor.field.Type().Elem()))
+	cursor.field.SetLen(length - 1)
+
+	cursor.iter.step--
+	cursor.node = nil
+}
+
+// InsertAfter inserts node after the current Node in its containing list.
+// If the current Node is not part of a list, InsertAfter panics. Apply
+// does not walk node.
+func (cursor *Cursor) InsertAfter(node Node) {
+	index := cursor.listIndex("InsertAfter")
+
+	cursor.insert(index+1, node)
+	cursor.iter.step++
+}
+
+// InsertBefore inserts node before the current Node in its containing list.
+// If the current Node is not part of a list, InsertBefore panics. Apply
+// does not walk node.
+func (cursor *Cursor) InsertBefore(node Node) {
+	index := cursor.listIndex("InsertBefore")
+
+	cursor.insert(index, node)
+	cursor.iter.index++
+}
+
+func (cursor *Cursor) listIndex(operation string) int {
+	if cursor.iter == nil {
+		panic(fmt.Sprintf("ast.Cursor.%s: node is not contained in a list", operation))
+	}
+
+	return cursor.iter.index
+}
+
+func (cursor *Cursor) insert(index int, node Node) {
+	list := cursor.field
+	list.Set(reflect.Append(list, reflect.Zero(list.Type().Elem())))
+
+	reflect.Copy(list.Slice(index+1, list.Len()), list.Slice(index, list.Len()))
+	list.Index(index).Set(reflect.ValueOf(node))
+}
+
+type application struct {
+	pre, post ApplyFunc
+	cursor    Cursor
+	iter      iterator
+}
+
+func (application *application) apply(parent Node, name string, field reflect.Value, iter *iterator, node Node) {
+	saved := application.cursor
+	application.cursor = Cursor{parent: parent, name: name, field: field, iter: iter, node: node}
+
+	if application.pre != nil && !application.pre(&application.cursor) || application.cursor.node == nil {
+		application.cursor = saved
+		return
+	}
+
+	switch n := application.cursor.node.(type) {
+	case *Program:
+		application.applyList(n, "Statemens")
+
+	// Statements
+	case *LetStatement:
+		application.applyChildren(n, "Name", "Pattern", "Type", "Value")
+
+	case *ReturnStatement:
+		application.applyChildren(n, "ReturnValue")
+
+	case *ExpressionStatement:
+		application.applyChildren(n, "Expression")
+
+	case *BlockStatement:
+		application.applyList(n, "Statements")
+
+	case *ImportStatement:
+		application.applyChildren(n, "Path", "Alias")
+
+	case *ExportStatement:
+		application.applyChildren(n, "Declaration")
+
+	case *ThrowStatement:
+		application.applyChildren(n, "Value")
+
+	case *TryStatement:
+		application.applyChildren(n, "Block", "CatchParameter", "Catch", "Finally")
+
+	case *DeferStatement:
+		application.applyChildren(n, "Call")
+
+	case *SelectStatement:
+		application.applyList(n, "Cases")
+		application.applyChildren(n, "Default")
+
+	case *SelectCase:
+		application.applyChildren(n, "Binding", "Operation", "Body")
+
+	case *StructStatement:
+		application.applyChildren(n, "Name")
+		application.applyList(n, "Fields")
+
+	case *EnumStatement:
+		application.applyChildren(n, "Name")
+		application.applyList(n, "Variants")
+
+	case *EnumVariant:
+		application.applyChildren(n, "Name")
+		application.applyList(n, "Fields")
+
+	// Expressions
+	case *Identifier, *IntegerLiteral, *Boolean, *StringLiteral, *TemplateString:
+		// nothing to do
+
+	case *PrefixExpression:
+		application.applyChildren(n, "Right")
+
+	case *InfixExpression:
+		application.applyChildren(n, "Left", "Right")
+
+	case *IfExpression:
+		application.applyChildren(n, "Condition", "Consequence", "Alternative")
+
+	case *FunctionLiteral:
+		application.applyList(n, "Parameters")
+		application.applyChildren(n, "ReturnType", "Body")
+
+	case *Parameter:
+		application.applyChildren(n, "Pattern", "Type", "Default")
+
+	case *CallExpression:
+		application.applyChildren(n, "Function")
+		application.applyList(n, "Arguments")
+
+	case *SpreadExpression:
+		application.applyChildren(n, "Value")
+
+	case *NamedArgument:
+		application.applyChildren(n, "Name", "Value")
+
+	case *TemplateLiteral:
+		application.applyList(n, "Parts")
+
+	case *MemberExpression:
+		application.applyChildren(n, "Object", "Property")
+
+	case *SpawnExpression:
+		application.applyChildren(n, "Call")
+
+	case *YieldExpression:
+		application.applyChildren(n, "Value")
+
+	case *ArrayLiteral:
+		application.applyList(n, "Elements")
+
+	case *IndexExpression:
+		application.applyChildren(n, "Left", "Index")
+
+	case *SliceExpression:
+		application.applyChildren(n, "Left", "Low", "High", "Step")
+
+	case *HashLiteral:
+		for _, pair := range n.Pairs {
+			application.applyPairField(n, pair, "Key")
+			application.applyPairField(n, pair, "Value")
+		}
+
+	case *MatchExpression:
+		application.applyChildren(n, "Subject")
+		application.applyList(n, "Arms")
+
+	case *MatchArm:
+		application.applyChildren(n, "Pattern", "Body")
+
+	// Patterns
+	case *VariantPattern:
+		application.applyChildren(n, "Enum", "Variant")
+		application.applyList(n, "Bindings")
+
+	case *LiteralPattern:
+		application.applyChildren(n, "Value")
+
+	case *ArrayPattern:
+		application.applyList(n, "Elements")
+		application.applyChildren(n, "Rest")
+
+	case *HashPattern:
+		for _, pair := range n.Pairs {
+			// the shorthand {name} shares one identifier for key and value
+			shorthand := pair.Value == Pattern(pair.Key)
+
+			application.applyPairField(n, pair, "Key")
+
+			if shorthand {
+				pair.Value = pair.Key
+			} else {
+				application.applyPairField(n, pair, "Value")
+			}
+		}
+		application.applyChildren(n, "Rest")
+
+	// Types
+	case *TypeName:
+		// nothing to do
+
+	case *ArrayType:
+		application.applyChildren(n, "Element")
+
+	case *HashType:
+		application.applyChildren(n, "Key", "Value")
+
+	case *FunctionType:
+		application.applyList(n, "Parameters")
+		application.applyChildren(n, "Result")
+
+	default:
+		panic(fmt.Sprintf("ast.Apply: unexpected node type %T", n))
+	}
+
+	if application.post != nil && !application.post(&application.cursor) {
+		panic(abort)
+	}
+
+	application.cursor = saved
+}
+
+// applyChildren applies to the nodes held by the named fields of parent.
+func (application *application) applyChildren(parent Node, names ...string) {
+	for _, name := range names {
+		application.applyField(parent, name, reflect.ValueOf(parent).Elem().FieldByName(name))
+	}
+}
+
+func (application *application) applyPairField(parent Node, pair interface{}, name string) {
+	application.applyField(parent, name, reflect.ValueOf(pair).Elem().FieldByName(name))
+}
+
+func (application *application) applyField(parent Node, name string, field reflect.Value) {
+	if field.IsNil() {
+		return
+	}
+
+	application.apply(parent, name, field, nil, field.Interface().(Node))
+}
+
+// applyList applies to each element of the named list field of parent.
+// The list is re-read after every element, since the cursor may have
+// inserted or deleted elements.
+func (application *application) applyList(parent Node, name string) {
+	field := reflect.ValueOf(parent).Elem().FieldByName(name)
+
+	saved := application.iter
+	application.iter.index = 0
+
+	for application.iter.index < field.Len() {
+		application.iter.step = 1
+
+		if element := field.Index(application.iter.index); !element.IsNil() {
+			application.apply(parent, name, field, &application.iter, element.Interface().(Node))
+		}
+
+		application.iter.index += application.iter.step
+	}
+
+	application.iter = saved
+}
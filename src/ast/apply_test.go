@@ -0,0 +1,236 @@
+package ast_test
+
+import (
+	"monkey/ast"
+	"monkey/token"
+	"testing"
+)
+
+func TestApplyVisitsSameNodesAsWalk(t *testing.T) {
+	program := parse(t, walkInput)
+
+	walked := []ast.Node{}
+	ast.Inspect(program, func(node ast.Node) bool {
+		if node != nil {
+			walked = append(walked, node)
+		}
+
+		return true
+	})
+
+	applied := []ast.Node{}
+	ast.Apply(program, func(cursor *ast.Cursor) bool {
+		applied = append(applied, cursor.Node())
+
+		return true
+	}, nil)
+
+	if len(applied) != len(walked) {
+		t.Fatalf("Apply visited %d nodes, Walk visited %d", len(applied), len(walked))
+	}
+
+	for i := range walked {
+		if applied[i] != walked[i] {
+			t.Fatalf("node %d differs. Walk=%s, Apply=%s", i, walked[i].String(), applied[i].String())
+		}
+	}
+}
+
+func TestModify(t *testing.T) {
+	one := func() *ast.IntegerLiteral {
+		return &ast.IntegerLiteral{Token: token.Token{Type: token.INT, Literal: "1"}, Value: 1}
+	}
+	two := func() *ast.IntegerLiteral {
+		return &ast.IntegerLiteral{Token: token.Token{Type: token.INT, Literal: "2"}, Value: 2}
+	}
+
+	turnOneIntoTwo := func(node ast.Node) ast.Node {
+		if integer, ok := node.(*ast.IntegerLiteral); ok && integer.Value == 1 {
+			return two()
+		}
+
+		return node
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"1", "2"},
+		{"1 + 2", "(2 + 2)"},
+		{"-1", "(-2)"},
+		{"let x = 1;", "let x = 2;"},
+		{"return 1;", "return 2;"},
+		{"[1, 3, 1][1]", "([2, 3, 2][2])"},
+		{"f(1, x: 1)", "f(2, x: 2)"},
+		{"{1: 1}", "{2: 2}"},
+		{"fn(x = 1) { 1 }", "fn(x = 2) 2"},
+		{"x[1:3:1]", "(x[2:3:2])"},
+	}
+
+	for _, tt := range tests {
+		program := parse(t, tt.input)
+
+		modified := ast.Modify(program, turnOneIntoTwo)
+
+		if modified.String() != tt.expected {
+			t.Errorf("wrong result for %q. expected=%q, got=%q", tt.input, tt.expected, modified.String())
+		}
+	}
+
+	if root := ast.Modify(one(), turnOneIntoTwo); root.String() != "2" {
+		t.Errorf("root was not replaced. got=%q", root.String())
+	}
+}
+
+func TestModifyDeletesListElements(t *testing.T) {
+	program := parse(t, "debug(a); let f = fn() { debug(b); c; debug(d) }; e; debug(f);")
+
+	ast.Modify(program, func(node ast.Node) ast.Node {
+		if statement, ok := node.(*ast.ExpressionStatement); ok {
+			if call, ok := statement.Expression.(*ast.CallExpression); ok && call.Function.String() == "debug" {
+				return nil
+			}
+		}
+
+		return node
+	})
+
+	expected := "let f = fn() c;e"
+
+	if program.String() != expected {
+		t.Errorf("wrong program. expected=%q, got=%q", expected, program.String())
+	}
+}
+
+func TestApplyInsertsStatements(t *testing.T) {
+	program := parse(t, "a();\nlet f = fn() {\n  b();\n  c();\n};")
+
+	trace := func(name string) ast.Statement {
+		call := &ast.CallExpression{
+			Function:  &ast.Identifier{Token: token.Token{Type: token.IDENT, Literal: "trace"}, Value: "trace"},
+			Arguments: []ast.Expression{&ast.StringLiteral{Token: token.Token{Type: token.STRING, Literal: name}, Value: name}},
+		}
+
+		return &ast.ExpressionStatement{Expression: call}
+	}
+
+	visited := 0
+
+	ast.Apply(program, func(cursor *ast.Cursor) bool {
+		statement, ok := cursor.Node().(*ast.ExpressionStatement)
+
+		if !ok {
+			return true
+		}
+
+		visited++
+
+		if call, ok := statement.Expression.(*ast.CallExpression); ok {
+			name := call.Function.String()
+
+			cursor.InsertBefore(trace("enter " + name))
+			cursor.InsertAfter(trace("leave " + name))
+		}
+
+		return true
+	}, nil)
+
+	expected := "trace(enter a)a()trace(leave a)" +
+		"let f = fn() trace(enter b)b()trace(leave b)trace(enter c)c()trace(leave c);"
+
+	if program.String() != expected {
+		t.Errorf("wrong program.\nexpected=%q\ngot=     %q", expected, program.String())
+	}
+
+	// inserted statements must not be visited themselves
+	if visited != 3 {
+		t.Errorf("expected 3 expression statements to be visited, got=%d", visited)
+	}
+
+	// untouched nodes keep their positions
+	body := program.Statemens[3].(*ast.LetStatement).Value.(*ast.FunctionLiteral).Body
+
+	if tok := body.Statements[4].(*ast.ExpressionStatement).Token; tok.Line != 4 || tok.Column != 3 {
+		t.Errorf("statement c() moved to %d:%d, expected 4:3", tok.Line, tok.Column)
+	}
+}
+
+func TestCursorDescribesPosition(t *testing.T) {
+	program := parse(t, "let x = f(a, b); {k: v};")
+
+	type position struct {
+		parent string
+		name   string
+		index  int
+	}
+
+	expected := map[string]position{
+		"x": {"let x = f(a, b);", "Name", -1},
+		"f": {"f(a, b)", "Function", -1},
+		"a": {"f(a, b)", "Arguments", 0},
+		"b": {"f(a, b)", "Arguments", 1},
+		"k": {"{k: v}", "Key", -1},
+		"v": {"{k: v}", "Value", -1},
+	}
+
+	ast.Apply(program, func(cursor *ast.Cursor) bool {
+		identifier, ok := cursor.Node().(*ast.Identifier)
+
+		if !ok {
+			return true
+		}
+
+		got := position{cursor.Parent().String(), cursor.Name(), cursor.Index()}
+
+		if got != expected[identifier.Value] {
+			t.Errorf("wrong cursor for %s. expected=%+v, got=%+v", identifier.Value, expected[identifier.Value], got)
+		}
+
+		return true
+	}, nil)
+
+	ast.Apply(program, func(cursor *ast.Cursor) bool {
+		if cursor.Parent() != nil {
+			t.Errorf("expected root to have no parent, got=%s", cursor.Parent().String())
+		}
+
+		return false
+	}, nil)
+}
+
+func TestApplyStopsWhenPostReturnsFalse(t *testing.T) {
+	program := parse(t, "a; b; c;")
+
+	visited := []string{}
+
+	ast.Apply(program, nil, func(cursor *ast.Cursor) bool {
+		if identifier, ok := cursor.Node().(*ast.Identifier); ok {
+			visited = append(visited, identifier.Value)
+
+			return identifier.Value != "b"
+		}
+
+		return true
+	})
+
+	if len(visited) != 2 {
+		t.Errorf("expected traversal to stop after b, got=%v", visited)
+	}
+}
+
+func TestCursorListOperationsOutsideListPanic(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected Delete outside of a list to panic")
+		}
+	}()
+
+	ast.Apply(parse(t, "let x = 1;"), func(cursor *ast.Cursor) bool {
+		if _, ok := cursor.Node().(*ast.IntegerLiteral); ok {
+			cursor.Delete()
+		}
+
+		return true
+	}, nil)
+}
@@ -3,6 +3,8 @@ package ast
 import (
 	"bytes"
 	"monkey/token"
+	"strconv"
+	"strings"
 )
 
 type Node interface {
@@ -20,14 +22,29 @@ type Expression interface {
 	expressionNode()
 }
 
+// Pattern is the target of a binding: a plain identifier or a
+// destructuring array/hash pattern.
+type Pattern interface {
+	Node
+	patternNode()
+}
+
+// TypeExpression is a type annotation, such as int in let x: int = 5.
+type TypeExpression interface {
+	Node
+	typeNode()
+}
+
 type Program struct {
 	Statemens []Statement
 }
 
 type LetStatement struct {
-	Token token.Token
-	Name  *Identifier
-	Value Expression
+	Token   token.Token // the 'let' or 'const' token
+	Name    *Identifier
+	Pattern Pattern        // set instead of Name for destructuring bindings
+	Type    TypeExpression // nil when not annotated
+	Value   Expression
 }
 
 type Identifier struct {
@@ -60,6 +77,7 @@ func (letStatement *LetStatement) statementNode()       {}
 func (letStatement *LetStatement) TokenLiteral() string { return letStatement.Token.Literal }
 
 func (id *Identifier) expressionNode()      {}
+func (id *Identifier) patternNode()         {}
 func (id *Identifier) TokenLiteral() string { return id.Token.Literal }
 func (id *Identifier) String() string       { return id.Value }
 
@@ -73,6 +91,18 @@ func (il *IntegerLiteral) expressionNode()      {}
 func (il *IntegerLiteral) TokenLiteral() string { return il.Token.Literal }
 func (il *IntegerLiteral) String() string       { return il.Token.Literal }
 
+type InfixExpression struct {
+	Token    token.Token // the operator token, e.g. +
+	Left     Expression
+	Operator string
+	Right    Expression
+}
+
+type Boolean struct {
+	Token token.Token
+	Value bool
+}
+
 func (pe *PrefixExpression) expressionNode()      {}
 func (pe *PrefixExpression) TokenLiteral() string { return pe.Token.Literal }
 func (pe *PrefixExpression) String() string {
@@ -86,6 +116,24 @@ func (pe *PrefixExpression) String() string {
 	return out.String()
 }
 
+func (ie *InfixExpression) expressionNode()      {}
+func (ie *InfixExpression) TokenLiteral() string { return ie.Token.Literal }
+func (ie *InfixExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(ie.Left.String())
+	out.WriteString(" " + ie.Operator + " ")
+	out.WriteString(ie.Right.String())
+	out.WriteString(")")
+
+	return out.String()
+}
+
+func (b *Boolean) expressionNode()      {}
+func (b *Boolean) TokenLiteral() string { return b.Token.Literal }
+func (b *Boolean) String() string       { return b.Token.Literal }
+
 func (program *Program) TokenLiteral() string {
 	if len(program.Statemens) > 0 {
 		return program.Statemens[0].TokenLiteral()
@@ -104,11 +152,31 @@ func (program *Program) String() string {
 	return out.String()
 }
 
+// Target returns the binding target of the statement, whether it is a
+// single name or a destructuring pattern.
+func (ls *LetStatement) Target() Pattern {
+	if ls.Pattern != nil {
+		return ls.Pattern
+	}
+
+	return ls.Name
+}
+
+// IsConst reports whether the statement declares constants with const.
+func (ls *LetStatement) IsConst() bool {
+	return ls.Token.Type == token.CONST
+}
+
 func (ls *LetStatement) String() string {
 	var out bytes.Buffer
 
 	out.WriteString(ls.TokenLiteral() + " ")
-	out.WriteString(ls.Name.String())
+	out.WriteString(ls.Target().String())
+
+	if ls.Type != nil {
+		out.WriteString(": " + ls.Type.String())
+	}
+
 	out.WriteString(" = ")
 
 	if ls.Value != nil {
@@ -141,3 +209,858 @@ func (es *ExpressionStatement) String() string {
 
 	return ""
 }
+
+// ImportStatement loads another module: import "lib/strings" as str;
+type ImportStatement struct {
+	Token token.Token // the 'import' token
+	Path  *StringLiteral
+	Alias *Identifier // nil when no `as` clause is given
+}
+
+func (is *ImportStatement) statementNode()       {}
+func (is *ImportStatement) TokenLiteral() string { return is.Token.Literal }
+func (is *ImportStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(is.TokenLiteral() + " ")
+	out.WriteString(strconv.Quote(is.Path.Value))
+
+	if is.Alias != nil {
+		out.WriteString(" as ")
+		out.WriteString(is.Alias.String())
+	}
+
+	out.WriteString(";")
+
+	return out.String()
+}
+
+// ExportStatement makes the bindings of a declaration visible to importing
+// modules: export let x = 1;
+type ExportStatement struct {
+	Token       token.Token // the 'export' token
+	Declaration *LetStatement
+}
+
+func (es *ExportStatement) statementNode()       {}
+func (es *ExportStatement) TokenLiteral() string { return es.Token.Literal }
+func (es *ExportStatement) String() string {
+	return es.TokenLiteral() + " " + es.Declaration.String()
+}
+
+type ThrowStatement struct {
+	Token token.Token // the 'throw' token
+	Value Expression
+}
+
+func (ts *ThrowStatement) statementNode()       {}
+func (ts *ThrowStatement) TokenLiteral() string { return ts.Token.Literal }
+func (ts *ThrowStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(ts.TokenLiteral() + " ")
+
+	if ts.Value != nil {
+		out.WriteString(ts.Value.String())
+	}
+
+	out.WriteString(";")
+
+	return out.String()
+}
+
+// TryStatement is try { } catch (e) { } finally { }. Either the catch or the
+// finally clause may be missing, but not both; CatchParameter is optional.
+type TryStatement struct {
+	Token          token.Token // the 'try' token
+	Block          *BlockStatement
+	CatchParameter *Identifier
+	Catch          *BlockStatement
+	Finally        *BlockStatement
+}
+
+func (ts *TryStatement) statementNode()       {}
+func (ts *TryStatement) TokenLiteral() string { return ts.Token.Literal }
+func (ts *TryStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("try ")
+	out.WriteString(ts.Block.String())
+
+	if ts.Catch != nil {
+		out.WriteString(" catch ")
+
+		if ts.CatchParameter != nil {
+			out.WriteString("(" + ts.CatchParameter.String() + ") ")
+		}
+
+		out.WriteString(ts.Catch.String())
+	}
+
+	if ts.Finally != nil {
+		out.WriteString(" finally ")
+		out.WriteString(ts.Finally.String())
+	}
+
+	return out.String()
+}
+
+// DeferStatement schedules Call to run when the enclosing function returns.
+type DeferStatement struct {
+	Token token.Token // the 'defer' token
+	Call  Expression
+}
+
+func (ds *DeferStatement) statementNode()       {}
+func (ds *DeferStatement) TokenLiteral() string { return ds.Token.Literal }
+func (ds *DeferStatement) String() string {
+	return ds.TokenLiteral() + " " + ds.Call.String() + ";"
+}
+
+// SelectStatement waits on several channel operations and runs the body of
+// the first one that is ready, or Default when none is.
+type SelectStatement struct {
+	Token   token.Token // the 'select' token
+	Cases   []*SelectCase
+	Default *BlockStatement // nil when there is no default arm
+}
+
+func (ss *SelectStatement) statementNode()       {}
+func (ss *SelectStatement) TokenLiteral() string { return ss.Token.Literal }
+func (ss *SelectStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("select { ")
+
+	for _, c := range ss.Cases {
+		out.WriteString(c.String())
+		out.WriteString(" ")
+	}
+
+	if ss.Default != nil {
+		out.WriteString("default ")
+		out.WriteString(ss.Default.String())
+		out.WriteString(" ")
+	}
+
+	out.WriteString("}")
+
+	return out.String()
+}
+
+// SelectCase is one arm of a select: `case v = recv(ch) { }` or
+// `case send(ch, x) { }`. Binding is only allowed for recv.
+type SelectCase struct {
+	Token     token.Token // the 'case' token
+	Binding   *Identifier
+	Operation *CallExpression
+	Body      *BlockStatement
+}
+
+func (sc *SelectCase) TokenLiteral() string { return sc.Token.Literal }
+func (sc *SelectCase) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("case ")
+
+	if sc.Binding != nil {
+		out.WriteString(sc.Binding.String() + " = ")
+	}
+
+	out.WriteString(sc.Operation.String())
+	out.WriteString(" ")
+	out.WriteString(sc.Body.String())
+
+	return out.String()
+}
+
+// StructStatement declares a record type with a fixed set of fields:
+// struct Point { x, y }
+type StructStatement struct {
+	Token  token.Token // the 'struct' token
+	Name   *Identifier
+	Fields []*Identifier
+}
+
+func (ss *StructStatement) statementNode()       {}
+func (ss *StructStatement) TokenLiteral() string { return ss.Token.Literal }
+func (ss *StructStatement) String() string {
+	fields := []string{}
+	for _, f := range ss.Fields {
+		fields = append(fields, f.String())
+	}
+
+	if len(fields) == 0 {
+		return ss.TokenLiteral() + " " + ss.Name.String() + " {}"
+	}
+
+	return ss.TokenLiteral() + " " + ss.Name.String() + " { " + strings.Join(fields, ", ") + " }"
+}
+
+// EnumStatement declares a sum type whose variants may carry payloads:
+// enum Status { Pending, Done(result), Failed(err) }
+type EnumStatement struct {
+	Token    token.Token // the 'enum' token
+	Name     *Identifier
+	Variants []*EnumVariant
+}
+
+func (es *EnumStatement) statementNode()       {}
+func (es *EnumStatement) TokenLiteral() string { return es.Token.Literal }
+func (es *EnumStatement) String() string {
+	variants := []string{}
+	for _, v := range es.Variants {
+		variants = append(variants, v.String())
+	}
+
+	return es.TokenLiteral() + " " + es.Name.String() + " { " + strings.Join(variants, ", ") + " }"
+}
+
+// Variant returns the variant called name, or nil.
+func (es *EnumStatement) Variant(name string) *EnumVariant {
+	for _, v := range es.Variants {
+		if v.Name.Value == name {
+			return v
+		}
+	}
+
+	return nil
+}
+
+type EnumVariant struct {
+	Name   *Identifier
+	Fields []*Identifier // payload field names; empty for plain variants
+}
+
+func (ev *EnumVariant) TokenLiteral() string { return ev.Name.TokenLiteral() }
+func (ev *EnumVariant) String() string {
+	if len(ev.Fields) == 0 {
+		return ev.Name.String()
+	}
+
+	fields := []string{}
+	for _, f := range ev.Fields {
+		fields = append(fields, f.String())
+	}
+
+	return ev.Name.String() + "(" + strings.Join(fields, ", ") + ")"
+}
+
+type BlockStatement struct {
+	Token      token.Token // the { token; the if token of an else if
+	Statements []Statement
+}
+
+func (bs *BlockStatement) statementNode()       {}
+func (bs *BlockStatement) TokenLiteral() string { return bs.Token.Literal }
+func (bs *BlockStatement) String() string {
+	var out bytes.Buffer
+
+	for _, s := range bs.Statements {
+		out.WriteString(s.String())
+	}
+
+	return out.String()
+}
+
+// IfExpression is if (condition) { } else { }. An else if chain nests the
+// next IfExpression as the only statement of the Alternative block.
+type IfExpression struct {
+	Token       token.Token // the 'if' token
+	Condition   Expression
+	Consequence *BlockStatement
+	Alternative *BlockStatement // nil when there is no else clause
+}
+
+func (ie *IfExpression) expressionNode()      {}
+func (ie *IfExpression) TokenLiteral() string { return ie.Token.Literal }
+func (ie *IfExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("if")
+	out.WriteString(ie.Condition.String())
+	out.WriteString(" ")
+	out.WriteString(ie.Consequence.String())
+
+	if ie.Alternative != nil {
+		out.WriteString("else ")
+		out.WriteString(ie.Alternative.String())
+	}
+
+	return out.String()
+}
+
+type FunctionLiteral struct {
+	Token       token.Token // the 'fn' token
+	Parameters  []*Parameter
+	ReturnType  TypeExpression // nil when not annotated
+	Body        *BlockStatement
+	IsGenerator bool // declared as fn* or containing a yield
+	IsArrow     bool // written as (params) => body
+}
+
+func (fl *FunctionLiteral) expressionNode()      {}
+func (fl *FunctionLiteral) TokenLiteral() string { return fl.Token.Literal }
+func (fl *FunctionLiteral) String() string {
+	var out bytes.Buffer
+
+	params := []string{}
+	for _, p := range fl.Parameters {
+		params = append(params, p.String())
+	}
+
+	out.WriteString(fl.TokenLiteral())
+
+	if fl.IsGenerator {
+		out.WriteString("*")
+	}
+
+	out.WriteString("(")
+	out.WriteString(strings.Join(params, ", "))
+	out.WriteString(") ")
+
+	if fl.ReturnType != nil {
+		out.WriteString("-> " + fl.ReturnType.String() + " ")
+	}
+
+	out.WriteString(fl.Body.String())
+
+	return out.String()
+}
+
+// Parameter is a single entry of a function parameter list: a binding
+// pattern with an optional type and default value, or a trailing ...rest
+// parameter.
+type Parameter struct {
+	Pattern Pattern
+	Type    TypeExpression // nil when not annotated
+	Default Expression
+	Rest    bool
+}
+
+func (p *Parameter) TokenLiteral() string { return p.Pattern.TokenLiteral() }
+func (p *Parameter) String() string {
+	out := p.Pattern.String()
+
+	if p.Rest {
+		out = "..." + out
+	}
+
+	if p.Type != nil {
+		out += ": " + p.Type.String()
+	}
+
+	if p.Default != nil {
+		out += " = " + p.Default.String()
+	}
+
+	return out
+}
+
+type CallExpression struct {
+	Token     token.Token // the ( token, or |> for a bare pipeline target
+	Function  Expression  // Identifier or FunctionLiteral
+	Arguments []Expression
+	Piped     bool // desugared from `Arguments[0] |> Function(Arguments[1:])`
+}
+
+func (ce *CallExpression) expressionNode()      {}
+func (ce *CallExpression) TokenLiteral() string { return ce.Token.Literal }
+func (ce *CallExpression) String() string {
+	if ce.Piped {
+		return ce.pipelineString()
+	}
+
+	var out bytes.Buffer
+
+	args := []string{}
+	for _, a := range ce.Arguments {
+		args = append(args, a.String())
+	}
+
+	out.WriteString(ce.Function.String())
+	out.WriteString("(")
+	out.WriteString(strings.Join(args, ", "))
+	out.WriteString(")")
+
+	return out.String()
+}
+
+// pipelineString prints a piped call the way it was written: `(x |> f)`,
+// `(x |> f(y))` or, for a call used as the callee, `(x |> (f(y)))`.
+func (ce *CallExpression) pipelineString() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(ce.Arguments[0].String())
+	out.WriteString(" |> ")
+
+	if call, ok := ce.Function.(*CallExpression); ok && !call.Piped && ce.Token.Type == token.PIPE {
+		out.WriteString("(" + call.String() + ")")
+	} else {
+		out.WriteString(ce.Function.String())
+	}
+
+	if ce.Token.Type != token.PIPE {
+		args := []string{}
+		for _, a := range ce.Arguments[1:] {
+			args = append(args, a.String())
+		}
+
+		out.WriteString("(")
+		out.WriteString(strings.Join(args, ", "))
+		out.WriteString(")")
+	}
+
+	out.WriteString(")")
+
+	return out.String()
+}
+
+// SpreadExpression expands an array into the arguments of a call: f(...args)
+type SpreadExpression struct {
+	Token token.Token // the ... token
+	Value Expression
+}
+
+func (se *SpreadExpression) expressionNode()      {}
+func (se *SpreadExpression) TokenLiteral() string { return se.Token.Literal }
+func (se *SpreadExpression) String() string       { return "..." + se.Value.String() }
+
+// NamedArgument passes a value to a parameter by name: f(y: 2)
+type NamedArgument struct {
+	Token token.Token // the : token
+	Name  *Identifier
+	Value Expression
+}
+
+func (na *NamedArgument) expressionNode()      {}
+func (na *NamedArgument) TokenLiteral() string { return na.Token.Literal }
+func (na *NamedArgument) String() string       { return na.Name.String() + ": " + na.Value.String() }
+
+type StringLiteral struct {
+	Token token.Token
+	Value string
+}
+
+func (sl *StringLiteral) expressionNode()      {}
+func (sl *StringLiteral) TokenLiteral() string { return sl.Token.Literal }
+func (sl *StringLiteral) String() string       { return sl.Token.Literal }
+
+// TemplateLiteral is a backtick string with embedded expressions:
+// `Hello ${name}!`. Parts alternate freely between *TemplateString text
+// fragments and the embedded expressions, in source order.
+type TemplateLiteral struct {
+	Token token.Token // the opening ` token
+	Parts []Expression
+}
+
+func (tl *TemplateLiteral) expressionNode()      {}
+func (tl *TemplateLiteral) TokenLiteral() string { return tl.Token.Literal }
+func (tl *TemplateLiteral) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("`")
+
+	for _, part := range tl.Parts {
+		if text, ok := part.(*TemplateString); ok {
+			out.WriteString(text.String())
+		} else {
+			out.WriteString("${")
+			out.WriteString(part.String())
+			out.WriteString("}")
+		}
+	}
+
+	out.WriteString("`")
+
+	return out.String()
+}
+
+// TemplateString is a literal text fragment of a template literal.
+type TemplateString struct {
+	Token token.Token
+	Value string
+}
+
+var templateEscaper = strings.NewReplacer("\\", "\\\\", "`", "\\`", "${", "\\${")
+
+func (ts *TemplateString) expressionNode()      {}
+func (ts *TemplateString) TokenLiteral() string { return ts.Token.Literal }
+func (ts *TemplateString) String() string       { return templateEscaper.Replace(ts.Value) }
+
+// MemberExpression accesses a field or method by name: obj.field
+type MemberExpression struct {
+	Token    token.Token // the . token
+	Object   Expression
+	Property *Identifier
+}
+
+func (me *MemberExpression) expressionNode()      {}
+func (me *MemberExpression) TokenLiteral() string { return me.Token.Literal }
+func (me *MemberExpression) String() string {
+	return me.Object.String() + "." + me.Property.String()
+}
+
+// SpawnExpression runs Call in a new task and evaluates to a handle for it.
+type SpawnExpression struct {
+	Token token.Token // the 'spawn' token
+	Call  *CallExpression
+}
+
+func (se *SpawnExpression) expressionNode()      {}
+func (se *SpawnExpression) TokenLiteral() string { return se.Token.Literal }
+func (se *SpawnExpression) String() string       { return "(spawn " + se.Call.String() + ")" }
+
+// YieldExpression suspends a generator and hands Value to its consumer.
+// Value is nil for a bare `yield`.
+type YieldExpression struct {
+	Token token.Token // the 'yield' token
+	Value Expression
+}
+
+func (ye *YieldExpression) expressionNode()      {}
+func (ye *YieldExpression) TokenLiteral() string { return ye.Token.Literal }
+func (ye *YieldExpression) String() string {
+	if ye.Value == nil {
+		return "(yield)"
+	}
+
+	return "(yield " + ye.Value.String() + ")"
+}
+
+type ArrayLiteral struct {
+	Token    token.Token // the [ token
+	Elements []Expression
+}
+
+func (al *ArrayLiteral) expressionNode()      {}
+func (al *ArrayLiteral) TokenLiteral() string { return al.Token.Literal }
+func (al *ArrayLiteral) String() string {
+	var out bytes.Buffer
+
+	elements := []string{}
+	for _, el := range al.Elements {
+		elements = append(elements, el.String())
+	}
+
+	out.WriteString("[")
+	out.WriteString(strings.Join(elements, ", "))
+	out.WriteString("]")
+
+	return out.String()
+}
+
+type IndexExpression struct {
+	Token token.Token // the [ token
+	Left  Expression
+	Index Expression
+}
+
+func (ie *IndexExpression) expressionNode()      {}
+func (ie *IndexExpression) TokenLiteral() string { return ie.Token.Literal }
+func (ie *IndexExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(ie.Left.String())
+	out.WriteString("[")
+	out.WriteString(ie.Index.String())
+	out.WriteString("])")
+
+	return out.String()
+}
+
+// SliceExpression takes a slice of an array or string: s[low:high:step].
+// Each of Low, High and Step is optional and nil when omitted.
+type SliceExpression struct {
+	Token token.Token // the [ token
+	Left  Expression
+	Low   Expression
+	High  Expression
+	Step  Expression
+}
+
+func (se *SliceExpression) expressionNode()      {}
+func (se *SliceExpression) TokenLiteral() string { return se.Token.Literal }
+func (se *SliceExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(se.Left.String())
+	out.WriteString("[")
+
+	if se.Low != nil {
+		out.WriteString(se.Low.String())
+	}
+
+	out.WriteString(":")
+
+	if se.High != nil {
+		out.WriteString(se.High.String())
+	}
+
+	if se.Step != nil {
+		out.WriteString(":")
+		out.WriteString(se.Step.String())
+	}
+
+	out.WriteString("])")
+
+	return out.String()
+}
+
+// HashPair is a single key: value entry of a hash literal. Pairs are kept
+// in source order.
+type HashPair struct {
+	Key   Expression
+	Value Expression
+}
+
+type HashLiteral struct {
+	Token token.Token // the { token
+	Pairs []*HashPair
+}
+
+func (hl *HashLiteral) expressionNode()      {}
+func (hl *HashLiteral) TokenLiteral() string { return hl.Token.Literal }
+func (hl *HashLiteral) String() string {
+	var out bytes.Buffer
+
+	pairs := []string{}
+	for _, pair := range hl.Pairs {
+		pairs = append(pairs, pair.Key.String()+": "+pair.Value.String())
+	}
+
+	out.WriteString("{")
+	out.WriteString(strings.Join(pairs, ", "))
+	out.WriteString("}")
+
+	return out.String()
+}
+
+// MatchExpression selects the first arm whose pattern matches Subject:
+// match status { Done(r) => r, _ => 0 }
+type MatchExpression struct {
+	Token   token.Token // the 'match' token
+	Subject Expression
+	Arms    []*MatchArm
+}
+
+func (me *MatchExpression) expressionNode()      {}
+func (me *MatchExpression) TokenLiteral() string { return me.Token.Literal }
+func (me *MatchExpression) String() string {
+	arms := []string{}
+	for _, arm := range me.Arms {
+		arms = append(arms, arm.String())
+	}
+
+	return "match " + me.Subject.String() + " { " + strings.Join(arms, ", ") + " }"
+}
+
+type MatchArm struct {
+	Token   token.Token // the => token
+	Pattern Pattern
+	Body    *BlockStatement
+}
+
+func (ma *MatchArm) TokenLiteral() string { return ma.Token.Literal }
+func (ma *MatchArm) String() string {
+	return ma.Pattern.String() + " => " + ma.Body.String()
+}
+
+// VariantPattern matches an enum variant and binds its payload:
+// Done(result) or Status.Done(result). Enum is nil when unqualified.
+type VariantPattern struct {
+	Token    token.Token // the first token of the pattern
+	Enum     *Identifier
+	Variant  *Identifier
+	Bindings []Pattern
+}
+
+func (vp *VariantPattern) patternNode()         {}
+func (vp *VariantPattern) TokenLiteral() string { return vp.Token.Literal }
+func (vp *VariantPattern) String() string {
+	var out bytes.Buffer
+
+	if vp.Enum != nil {
+		out.WriteString(vp.Enum.String() + ".")
+	}
+
+	out.WriteString(vp.Variant.String())
+
+	if len(vp.Bindings) > 0 {
+		bindings := []string{}
+		for _, b := range vp.Bindings {
+			bindings = append(bindings, b.String())
+		}
+
+		out.WriteString("(" + strings.Join(bindings, ", ") + ")")
+	}
+
+	return out.String()
+}
+
+// LiteralPattern matches a value equal to a literal: 1, "ok", true.
+type LiteralPattern struct {
+	Token token.Token
+	Value Expression
+}
+
+func (lp *LiteralPattern) patternNode()         {}
+func (lp *LiteralPattern) TokenLiteral() string { return lp.Token.Literal }
+func (lp *LiteralPattern) String() string       { return lp.Value.String() }
+
+// ArrayPattern destructures an array: [a, [b, c], ...rest]
+type ArrayPattern struct {
+	Token    token.Token // the [ token
+	Elements []Pattern
+	Rest     *Identifier
+}
+
+func (ap *ArrayPattern) patternNode()         {}
+func (ap *ArrayPattern) TokenLiteral() string { return ap.Token.Literal }
+func (ap *ArrayPattern) String() string {
+	var out bytes.Buffer
+
+	elements := []string{}
+	for _, el := range ap.Elements {
+		elements = append(elements, el.String())
+	}
+
+	if ap.Rest != nil {
+		elements = append(elements, "..."+ap.Rest.String())
+	}
+
+	out.WriteString("[")
+	out.WriteString(strings.Join(elements, ", "))
+	out.WriteString("]")
+
+	return out.String()
+}
+
+// HashPatternPair binds the field Key to Value. In the shorthand form
+// {name} Value is an identifier with the same name as Key.
+type HashPatternPair struct {
+	Key   *Identifier
+	Value Pattern
+}
+
+// HashPattern destructures a hash: {name, age: years, ...rest}
+type HashPattern struct {
+	Token token.Token // the { token
+	Pairs []*HashPatternPair
+	Rest  *Identifier
+}
+
+func (hp *HashPattern) patternNode()         {}
+func (hp *HashPattern) TokenLiteral() string { return hp.Token.Literal }
+func (hp *HashPattern) String() string {
+	var out bytes.Buffer
+
+	pairs := []string{}
+	for _, pair := range hp.Pairs {
+		if ident, ok := pair.Value.(*Identifier); ok && ident.Value == pair.Key.Value {
+			pairs = append(pairs, pair.Key.String())
+		} else {
+			pairs = append(pairs, pair.Key.String()+": "+pair.Value.String())
+		}
+	}
+
+	if hp.Rest != nil {
+		pairs = append(pairs, "..."+hp.Rest.String())
+	}
+
+	out.WriteString("{")
+	out.WriteString(strings.Join(pairs, ", "))
+	out.WriteString("}")
+
+	return out.String()
+}
+
+// PatternNames returns every identifier bound by a pattern, in source order.
+func PatternNames(pattern Pattern) []*Identifier {
+	switch pattern := pattern.(type) {
+	case *Identifier:
+		return []*Identifier{pattern}
+
+	case *ArrayPattern:
+		names := []*Identifier{}
+
+		for _, element := range pattern.Elements {
+			names = append(names, PatternNames(element)...)
+		}
+
+		if pattern.Rest != nil {
+			names = append(names, pattern.Rest)
+		}
+
+		return names
+
+	case *HashPattern:
+		names := []*Identifier{}
+
+		for _, pair := range pattern.Pairs {
+			names = append(names, PatternNames(pair.Value)...)
+		}
+
+		if pattern.Rest != nil {
+			names = append(names, pattern.Rest)
+		}
+
+		return names
+	}
+
+	return nil
+}
+
+// TypeName is a named type: int, bool, string or any.
+type TypeName struct {
+	Token token.Token // the identifier token
+	Name  string
+}
+
+func (tn *TypeName) typeNode()            {}
+func (tn *TypeName) TokenLiteral() string { return tn.Token.Literal }
+func (tn *TypeName) String() string       { return tn.Name }
+
+// ArrayType is the type of arrays of Element: [int]
+type ArrayType struct {
+	Token   token.Token // the [ token
+	Element TypeExpression
+}
+
+func (at *ArrayType) typeNode()            {}
+func (at *ArrayType) TokenLiteral() string { return at.Token.Literal }
+func (at *ArrayType) String() string       { return "[" + at.Element.String() + "]" }
+
+// HashType is the type of hashes from Key to Value: {string: int}
+type HashType struct {
+	Token token.Token // the { token
+	Key   TypeExpression
+	Value TypeExpression
+}
+
+func (ht *HashType) typeNode()            {}
+func (ht *HashType) TokenLiteral() string { return ht.Token.Literal }
+func (ht *HashType) String() string {
+	return "{" + ht.Key.String() + ": " + ht.Value.String() + "}"
+}
+
+// FunctionType is the type of functions: fn(int, int) -> bool
+type FunctionType struct {
+	Token      token.Token // the 'fn' token
+	Parameters []TypeExpression
+	Result     TypeExpression
+}
+
+func (ft *FunctionType) typeNode()            {}
+func (ft *FunctionType) TokenLiteral() string { return ft.Token.Literal }
+func (ft *FunctionType) String() string {
+	parameters := []string{}
+	for _, parameter := range ft.Parameters {
+		parameters = append(parameters, parameter.String())
+	}
+
+	return "fn(" + strings.Join(parameters, ", ") + ") -> " + ft.Result.String()
+}
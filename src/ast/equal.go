@@ -0,0 +1,215 @@
+package ast
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"monkey/token"
+	"reflect"
+)
+
+type EqualOptions struct {
+	IgnorePositions bool // compare tokens by type and literal only
+}
+
+var tokenType = reflect.TypeOf(token.Token{})
+
+// Equal reports whether a and b are trees of the same node types with the
+// same fields and tokens. Unlike comparing String() output, it tells apart
+// nodes that print alike, such as the string "1" and the integer 1. A nil
+// slice equals an empty one.
+func Equal(a, b Node, options EqualOptions) bool {
+	return options.equal(reflect.ValueOf(&a).Elem(), reflect.ValueOf(&b).Elem())
+}
+
+func (options EqualOptions) equal(x, y reflect.Value) bool {
+	switch x.Kind() {
+	case reflect.Interface, reflect.Pointer:
+		if x.IsNil() || y.IsNil() {
+			return x.IsNil() == y.IsNil()
+		}
+
+		if x.Elem().Type() != y.Elem().Type() {
+			return false
+		}
+
+		return options.equal(x.Elem(), y.Elem())
+
+	case reflect.Struct:
+		if x.Type() == tokenType {
+			a, b := x.Interface().(token.Token), y.Interface().(token.Token)
+
+			if options.IgnorePositions {
+				a.Line, a.Column, b.Line, b.Column = 0, 0, 0, 0
+			}
+
+			return a == b
+		}
+
+		for i := 0; i < x.NumField(); i++ {
+			if !options.equal(x.Field(i), y.Field(i)) {
+				return false
+			}
+		}
+
+		return true
+
+	case reflect.Slice:
+		if x.Len() != y.Len() {
+			return false
+		}
+
+		for i := 0; i < x.Len(); i++ {
+			if !options.equal(x.Index(i), y.Index(i)) {
+				return false
+			}
+		}
+
+		return true
+
+	default:
+		return x.Interface() == y.Interface()
+	}
+}
+
+// Clone returns a deep copy of the tree rooted at node. Nodes that appear
+// more than once in the tree, like the key and value of a shorthand hash
+// pattern pair, are shared in the copy as well.
+func Clone(node Node) Node {
+	if node == nil {
+		return nil
+	}
+
+	clones := map[any]reflect.Value{}
+
+	return clone(reflect.ValueOf(node), clones).Interface().(Node)
+}
+
+func clone(x reflect.Value, clones map[any]reflect.Value) reflect.Value {
+	switch x.Kind() {
+	case reflect.Interface:
+		if x.IsNil() {
+			return reflect.Zero(x.Type())
+		}
+
+		result := reflect.New(x.Type()).Elem()
+		result.Set(clone(x.Elem(), clones))
+
+		return result
+
+	case reflect.Pointer:
+		if x.IsNil() {
+			return reflect.Zero(x.Type())
+		}
+
+		if result, ok := clones[x.Interface()]; ok {
+			return result
+		}
+
+		result := reflect.New(x.Type().Elem())
+		clones[x.Interface()] = result
+		result.Elem().Set(clone(x.Elem(), clones))
+
+		return result
+
+	case reflect.Struct:
+		result := reflect.New(x.Type()).Elem()
+
+		for i := 0; i < x.NumField(); i++ {
+			result.Field(i).Set(clone(x.Field(i), clones))
+		}
+
+		return result
+
+	case reflect.Slice:
+		if x.IsNil() {
+			return reflect.Zero(x.Type())
+		}
+
+		result := reflect.MakeSlice(x.Type(), x.Len(), x.Len())
+
+		for i := 0; i < x.Len(); i++ {
+			result.Index(i).Set(clone(x.Index(i), clones))
+		}
+
+		return result
+
+	default:
+		return x
+	}
+}
+
+// Hash returns a structural hash of the tree rooted at node that ignores
+// positions: trees that are Equal with IgnorePositions have the same hash,
+// so it can key a cache of compiled forms of identical snippets.
+func Hash(node Node) uint64 {
+	hash := fnv.New64a()
+	writeHash(hash, reflect.ValueOf(&node).Elem())
+
+	return hash.Sum64()
+}
+
+func writeHash(out io.Writer, x reflect.Value) {
+	switch x.Kind() {
+	case reflect.Interface, reflect.Pointer:
+		if x.IsNil() {
+			out.Write([]byte{0})
+			return
+		}
+
+		if x.Kind() == reflect.Interface {
+			writeString(out, x.Elem().Type().String())
+		}
+
+		out.Write([]byte{1})
+		writeHash(out, x.Elem())
+
+	case reflect.Struct:
+		if x.Type() == tokenType {
+			tok := x.Interface().(token.Token)
+			writeString(out, string(tok.Type))
+			writeString(out, tok.Literal)
+
+			return
+		}
+
+		for i := 0; i < x.NumField(); i++ {
+			writeHash(out, x.Field(i))
+		}
+
+	case reflect.Slice:
+		writeInt(out, uint64(x.Len()))
+
+		for i := 0; i < x.Len(); i++ {
+			writeHash(out, x.Index(i))
+		}
+
+	case reflect.String:
+		writeString(out, x.String())
+
+	case reflect.Bool:
+		if x.Bool() {
+			out.Write([]byte{1})
+		} else {
+			out.Write([]byte{0})
+		}
+
+	case reflect.Int, reflect.Int64:
+		writeInt(out, uint64(x.Int()))
+
+	default:
+		panic(fmt.Sprintf("ast.Hash: unexpected field kind %s", x.Kind()))
+	}
+}
+
+// writeString writes s prefixed by its length, so that consecutive strings
+// cannot run into each other.
+func writeString(out io.Writer, s string) {
+	writeInt(out, uint64(len(s)))
+	out.Write([]byte(s))
+}
+
+func writeInt(out io.Writer, n uint64) {
+	out.Write(binary.LittleEndian.AppendUint64(nil, n))
+}
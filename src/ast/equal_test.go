@@ -0,0 +1,117 @@
+package ast_test
+
+import (
+	"monkey/ast"
+	"testing"
+)
+
+func TestEqual(t *testing.T) {
+	tests := []struct {
+		a, b                   string
+		equal                  bool
+		equalIgnoringPositions bool
+	}{
+		{"let x = 1 + 2;", "let x = 1 + 2;", true, true},
+		{"let x = 1 + 2;", "let  x = 1 +\n2;", false, true},
+		{`f("1");`, "f(1);", false, false},
+		{"x |> f;", "f(x);", false, false},
+		{"let [a] = b;", "let [a, ...c] = b;", false, false},
+		{"fn(a) { a };", "fn(a) { a; };", true, true},
+		{"fn(a) { a };", "(a) => a;", false, false},
+	}
+
+	for _, tt := range tests {
+		a, b := parse(t, tt.a), parse(t, tt.b)
+
+		if got := ast.Equal(a, b, ast.EqualOptions{}); got != tt.equal {
+			t.Errorf("Equal(%q, %q) = %t, expected %t", tt.a, tt.b, got, tt.equal)
+		}
+
+		options := ast.EqualOptions{IgnorePositions: true}
+
+		if got := ast.Equal(a, b, options); got != tt.equalIgnoringPositions {
+			t.Errorf("Equal(%q, %q) ignoring positions = %t, expected %t", tt.a, tt.b, got, tt.equalIgnoringPositions)
+		}
+
+		if tt.equalIgnoringPositions && ast.Hash(a) != ast.Hash(b) {
+			t.Errorf("expected %q and %q to have the same hash", tt.a, tt.b)
+		}
+	}
+
+	if !ast.Equal(nil, nil, ast.EqualOptions{}) || ast.Equal(nil, parse(t, "x;"), ast.EqualOptions{}) {
+		t.Errorf("wrong result for nil nodes")
+	}
+}
+
+func TestHashTellsTreesApart(t *testing.T) {
+	inputs := []string{
+		"1 + 2;", "2 + 1;", "1 - 2;", `"1" + 2;`, "a;", "b;", "ab;", "a; b;",
+		"f(a, b);", "f(ab);", "[a, b];", "[[a], b];", "let [a] = b;", "let [...a] = b;",
+	}
+
+	seen := map[uint64]string{}
+
+	for _, input := range inputs {
+		hash := ast.Hash(parse(t, input))
+
+		if other, ok := seen[hash]; ok {
+			t.Errorf("%q and %q have the same hash", input, other)
+		}
+
+		seen[hash] = input
+	}
+}
+
+func TestClone(t *testing.T) {
+	program := parse(t, walkInput)
+	clone := ast.Clone(program).(*ast.Program)
+
+	if !ast.Equal(program, clone, ast.EqualOptions{}) {
+		t.Fatalf("clone differs from the original.\nexpected=%q\ngot=     %q", program.String(), clone.String())
+	}
+
+	if ast.Hash(program) != ast.Hash(clone) {
+		t.Errorf("clone has a different hash")
+	}
+
+	// no node may be shared between the original and the clone
+	original := map[ast.Node]bool{}
+	ast.Inspect(program, func(node ast.Node) bool {
+		original[node] = true
+		return true
+	})
+
+	ast.Inspect(clone, func(node ast.Node) bool {
+		if node != nil && original[node] {
+			t.Errorf("clone shares %T %q with the original", node, node.String())
+		}
+
+		return true
+	})
+
+	// changing the clone leaves the original alone
+	clone.Statemens[0].(*ast.LetStatement).Name.Value = "changed"
+
+	if program.Statemens[0].(*ast.LetStatement).Name.Value == "changed" {
+		t.Errorf("changing the clone changed the original")
+	}
+
+	if ast.Clone(nil) != nil {
+		t.Errorf("expected Clone(nil) to be nil")
+	}
+}
+
+func TestCloneKeepsShorthandHashPatterns(t *testing.T) {
+	program := parse(t, "let {a, b: c} = h;")
+	clone := ast.Clone(program).(*ast.Program)
+
+	pairs := clone.Statemens[0].(*ast.LetStatement).Pattern.(*ast.HashPattern).Pairs
+
+	if pairs[0].Value != ast.Pattern(pairs[0].Key) {
+		t.Errorf("expected shorthand pair to share key and value")
+	}
+
+	if pairs[1].Value == ast.Pattern(pairs[1].Key) {
+		t.Errorf("expected b: c to have its own value")
+	}
+}
@@ -0,0 +1,301 @@
+package ast
+
+import "fmt"
+
+// A Visitor's Visit method is invoked for each node encountered by Walk.
+// If the result visitor w is not nil, Walk visits each of the children
+// of node with the visitor w, followed by a call of w.Visit(nil).
+type Visitor interface {
+	Visit(node Node) (w Visitor)
+}
+
+// Walk traverses an AST in depth-first order: It starts by calling
+// v.Visit(node); node must not be nil. If the visitor w returned by
+// v.Visit(node) is not nil, Walk is invoked recursively with visitor
+// w for each of the non-nil children of node, followed by a call of
+// w.Visit(nil). Children are visited in the order of the node's fields.
+func Walk(v Visitor, node Node) {
+	if v = v.Visit(node); v == nil {
+		return
+	}
+
+	switch n := node.(type) {
+	case *Program:
+		walkStatements(v, n.Statemens)
+
+	// Statements
+	case *LetStatement:
+		if n.Name != nil {
+			Walk(v, n.Name)
+		}
+		walkPattern(v, n.Pattern)
+		walkType(v, n.Type)
+		walkExpression(v, n.Value)
+
+	case *ReturnStatement:
+		walkExpression(v, n.ReturnValue)
+
+	case *ExpressionStatement:
+		walkExpression(v, n.Expression)
+
+	case *BlockStatement:
+		walkStatements(v, n.Statements)
+
+	case *ImportStatement:
+		if n.Path != nil {
+			Walk(v, n.Path)
+		}
+		if n.Alias != nil {
+			Walk(v, n.Alias)
+		}
+
+	case *ExportStatement:
+		if n.Declaration != nil {
+			Walk(v, n.Declaration)
+		}
+
+	case *ThrowStatement:
+		walkExpression(v, n.Value)
+
+	case *TryStatement:
+		walkBlock(v, n.Block)
+		if n.CatchParameter != nil {
+			Walk(v, n.CatchParameter)
+		}
+		walkBlock(v, n.Catch)
+		walkBlock(v, n.Finally)
+
+	case *DeferStatement:
+		walkExpression(v, n.Call)
+
+	case *SelectStatement:
+		for _, c := range n.Cases {
+			Walk(v, c)
+		}
+		walkBlock(v, n.Default)
+
+	case *SelectCase:
+		if n.Binding != nil {
+			Walk(v, n.Binding)
+		}
+		if n.Operation != nil {
+			Walk(v, n.Operation)
+		}
+		walkBlock(v, n.Body)
+
+	case *StructStatement:
+		Walk(v, n.Name)
+		walkIdentifiers(v, n.Fields)
+
+	case *EnumStatement:
+		Walk(v, n.Name)
+		for _, variant := range n.Variants {
+			Walk(v, variant)
+		}
+
+	case *EnumVariant:
+		Walk(v, n.Name)
+		walkIdentifiers(v, n.Fields)
+
+	// Expressions
+	case *Identifier, *IntegerLiteral, *Boolean, *StringLiteral, *TemplateString:
+		// nothing to do
+
+	case *PrefixExpression:
+		walkExpression(v, n.Right)
+
+	case *InfixExpression:
+		walkExpression(v, n.Left)
+		walkExpression(v, n.Right)
+
+	case *IfExpression:
+		walkExpression(v, n.Condition)
+		walkBlock(v, n.Consequence)
+		walkBlock(v, n.Alternative)
+
+	case *FunctionLiteral:
+		for _, parameter := range n.Parameters {
+			Walk(v, parameter)
+		}
+		walkType(v, n.ReturnType)
+		walkBlock(v, n.Body)
+
+	case *Parameter:
+		walkPattern(v, n.Pattern)
+		walkType(v, n.Type)
+		walkExpression(v, n.Default)
+
+	case *CallExpression:
+		walkExpression(v, n.Function)
+		walkExpressions(v, n.Arguments)
+
+	case *SpreadExpression:
+		walkExpression(v, n.Value)
+
+	case *NamedArgument:
+		Walk(v, n.Name)
+		walkExpression(v, n.Value)
+
+	case *TemplateLiteral:
+		walkExpressions(v, n.Parts)
+
+	case *MemberExpression:
+		walkExpression(v, n.Object)
+		Walk(v, n.Property)
+
+	case *SpawnExpression:
+		if n.Call != nil {
+			Walk(v, n.Call)
+		}
+
+	case *YieldExpression:
+		walkExpression(v, n.Value)
+
+	case *ArrayLiteral:
+		walkExpressions(v, n.Elements)
+
+	case *IndexExpression:
+		walkExpression(v, n.Left)
+		walkExpression(v, n.Index)
+
+	case *SliceExpression:
+		walkExpression(v, n.Left)
+		walkExpression(v, n.Low)
+		walkExpression(v, n.High)
+		walkExpression(v, n.Step)
+
+	case *HashLiteral:
+		for _, pair := range n.Pairs {
+			walkExpression(v, pair.Key)
+			walkExpression(v, pair.Value)
+		}
+
+	case *MatchExpression:
+		walkExpression(v, n.Subject)
+		for _, arm := range n.Arms {
+			Walk(v, arm)
+		}
+
+	case *MatchArm:
+		walkPattern(v, n.Pattern)
+		walkBlock(v, n.Body)
+
+	// Patterns
+	case *VariantPattern:
+		if n.Enum != nil {
+			Walk(v, n.Enum)
+		}
+		Walk(v, n.Variant)
+		for _, binding := range n.Bindings {
+			walkPattern(v, binding)
+		}
+
+	case *LiteralPattern:
+		walkExpression(v, n.Value)
+
+	case *ArrayPattern:
+		for _, element := range n.Elements {
+			walkPattern(v, element)
+		}
+		if n.Rest != nil {
+			Walk(v, n.Rest)
+		}
+
+	case *HashPattern:
+		for _, pair := range n.Pairs {
+			Walk(v, pair.Key)
+
+			// the shorthand {name} shares one identifier for key and value
+			if pair.Value != Pattern(pair.Key) {
+				walkPattern(v, pair.Value)
+			}
+		}
+		if n.Rest != nil {
+			Walk(v, n.Rest)
+		}
+
+	// Types
+	case *TypeName:
+		// nothing to do
+
+	case *ArrayType:
+		walkType(v, n.Element)
+
+	case *HashType:
+		walkType(v, n.Key)
+		walkType(v, n.Value)
+
+	case *FunctionType:
+		for _, parameter := range n.Parameters {
+			walkType(v, parameter)
+		}
+		walkType(v, n.Result)
+
+	default:
+		panic(fmt.Sprintf("ast.Walk: unexpected node type %T", n))
+	}
+
+	v.Visit(nil)
+}
+
+func walkStatements(v Visitor, list []Statement) {
+	for _, statement := range list {
+		if statement != nil {
+			Walk(v, statement)
+		}
+	}
+}
+
+func walkExpressions(v Visitor, list []Expression) {
+	for _, expression := range list {
+		walkExpression(v, expression)
+	}
+}
+
+func walkIdentifiers(v Visitor, list []*Identifier) {
+	for _, identifier := range list {
+		Walk(v, identifier)
+	}
+}
+
+func walkExpression(v Visitor, expression Expression) {
+	if expression != nil {
+		Walk(v, expression)
+	}
+}
+
+func walkPattern(v Visitor, pattern Pattern) {
+	if pattern != nil {
+		Walk(v, pattern)
+	}
+}
+
+func walkType(v Visitor, typ TypeExpression) {
+	if typ != nil {
+		Walk(v, typ)
+	}
+}
+
+func walkBlock(v Visitor, block *BlockStatement) {
+	if block != nil {
+		Walk(v, block)
+	}
+}
+
+type inspector func(Node) bool
+
+func (f inspector) Visit(node Node) Visitor {
+	if f(node) {
+		return f
+	}
+
+	return nil
+}
+
+// Inspect traverses an AST in depth-first order: It starts by calling
+// f(node); node must not be nil. If f returns true, Inspect invokes f
+// recursively for each of the non-nil children of node, followed by a
+// call of f(nil).
+func Inspect(node Node, f func(Node) bool) {
+	Walk(inspector(f), node)
+}
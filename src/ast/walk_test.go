@@ -0,0 +1,177 @@
+package ast_test
+
+import (
+	"fmt"
+	"monkey/ast"
+	"monkey/lexer"
+	"monkey/parser"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// walkInput puts a distinct identifier or type name into every child field
+// of every node type, so that the order of visited identifiers shows which fields
+// Walk descends into. Each @ is replaced by the next name from walkName.
+var walkInput, walkNames = numberPlaceholders(`
+let @ = @;
+let [@, ...@] = @;
+let {@, @: @, ...@} = @;
+let @: {@: [@]} = @;
+return @;
+import "p" as @;
+export let @ = @;
+throw @;
+try { @ } catch (@) { @ } finally { @ }
+fn(@, [@] = @, ...@) { defer @(); yield @; };
+fn(@: @ = @, ...@: [@]) -> fn(@, @) -> @ { @ };
+select { case @ = recv(@) { @ } default { @ } }
+struct Shape { @, @ };
+enum Color { Red(@), Green };
+match @ { Red(@) => @, Color.Green => { @ }, 5 => @, [@] => @ };
+-@ + @ * @;
+@(@, ...@, @: @);
+` + "`t ${@} u`;" + `
+@.@;
+spawn @(@);
+[@][@];
+@[@:@:@];
+{@: @};
+if (@) { @ } else { @ }
+@ |> @(@);
+`)
+
+// numberPlaceholders replaces each @ in input with a distinct name: va, vb,
+// and so on.
+func numberPlaceholders(input string) (string, []string) {
+	names := []string{}
+
+	for strings.Contains(input, "@") {
+		name := fmt.Sprintf("v%c%c", 'a'+len(names)/26, 'a'+len(names)%26)
+		names = append(names, name)
+		input = strings.Replace(input, "@", name, 1)
+	}
+
+	return input, names
+}
+
+func parse(t *testing.T, input string) *ast.Program {
+	t.Helper()
+
+	newParser := parser.NewParser(lexer.NewLexer(input))
+	program := newParser.ParseProgram()
+
+	if errors := newParser.Errors(); len(errors) > 0 {
+		t.Fatalf("parser errors: %v", errors)
+	}
+
+	return program
+}
+
+func TestWalkVisitsEveryChildField(t *testing.T) {
+	program := parse(t, walkInput)
+
+	visited := []string{}
+
+	ast.Inspect(program, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.Identifier:
+			if strings.HasPrefix(node.Value, "v") {
+				visited = append(visited, node.Value)
+			}
+		case *ast.TypeName:
+			if strings.HasPrefix(node.Name, "v") {
+				visited = append(visited, node.Name)
+			}
+		}
+
+		return true
+	})
+
+	expected := append([]string{}, walkNames...)
+
+	// a piped call visits its callee before the piped-in value
+	last := len(expected) - 1
+	expected[last-2], expected[last-1] = expected[last-1], expected[last-2]
+
+	if strings.Join(visited, " ") != strings.Join(expected, " ") {
+		t.Errorf("wrong identifiers visited.\nexpected=%v\ngot=     %v", expected, visited)
+	}
+}
+
+func TestWalkVisitsEveryNodeType(t *testing.T) {
+	program := parse(t, walkInput+"true; \"s\"; (x) => x;")
+
+	types := map[string]bool{}
+
+	ast.Inspect(program, func(node ast.Node) bool {
+		if node != nil {
+			types[reflect.TypeOf(node).Elem().Name()] = true
+		}
+
+		return true
+	})
+
+	expected := []string{
+		"Program", "LetStatement", "ReturnStatement", "ExpressionStatement", "BlockStatement",
+		"ImportStatement", "ExportStatement", "ThrowStatement", "TryStatement", "DeferStatement",
+		"SelectStatement", "SelectCase", "StructStatement", "EnumStatement", "EnumVariant",
+		"Identifier", "IntegerLiteral", "Boolean", "StringLiteral", "TemplateLiteral", "TemplateString",
+		"PrefixExpression", "InfixExpression", "IfExpression", "FunctionLiteral", "Parameter", "CallExpression",
+		"SpreadExpression", "NamedArgument", "MemberExpression", "SpawnExpression", "YieldExpression",
+		"ArrayLiteral", "IndexExpression", "SliceExpression", "HashLiteral", "MatchExpression",
+		"MatchArm", "VariantPattern", "LiteralPattern", "ArrayPattern", "HashPattern",
+		"TypeName", "ArrayType", "HashType", "FunctionType",
+	}
+
+	for _, name := range expected {
+		if !types[name] {
+			t.Errorf("node type %s was not visited", name)
+		}
+	}
+}
+
+type countingVisitor struct {
+	enter, exit *int
+}
+
+func (visitor countingVisitor) Visit(node ast.Node) ast.Visitor {
+	if node == nil {
+		*visitor.exit++
+	} else {
+		*visitor.enter++
+	}
+
+	return visitor
+}
+
+func TestWalkCallsVisitNilAfterChildren(t *testing.T) {
+	program := parse(t, walkInput)
+
+	enter, exit := 0, 0
+	ast.Walk(countingVisitor{&enter, &exit}, program)
+
+	if enter == 0 || enter != exit {
+		t.Errorf("Visit(nil) calls do not match visited nodes. enter=%d, exit=%d", enter, exit)
+	}
+}
+
+func TestInspectPrunesSubtrees(t *testing.T) {
+	program := parse(t, "let f = fn(x) { inner }; outer;")
+
+	visited := []string{}
+
+	ast.Inspect(program, func(node ast.Node) bool {
+		if ident, ok := node.(*ast.Identifier); ok {
+			visited = append(visited, ident.Value)
+		}
+
+		_, isFunction := node.(*ast.FunctionLiteral)
+
+		return !isFunction
+	})
+
+	if strings.Join(visited, " ") != "f outer" {
+		t.Errorf("expected function body to be skipped. got=%v", visited)
+	}
+}
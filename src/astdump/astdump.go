@@ -0,0 +1,201 @@
+// Package astdump renders syntax trees for people: as indented
+// S-expressions and as Graphviz DOT graphs.
+package astdump
+
+import (
+	"bytes"
+	"fmt"
+	"monkey/ast"
+	"monkey/token"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+type Options struct {
+	Positions bool // label every node with the line:column of its token
+}
+
+// tree is a node prepared for rendering: its label and the children in
+// the order ast.Walk visits them, each with the field that holds it.
+type tree struct {
+	label    string
+	field    string
+	children []*tree
+}
+
+func buildTree(root ast.Node, options Options) *tree {
+	stack := []*tree{{}}
+
+	ast.Apply(root, func(cursor *ast.Cursor) bool {
+		node := &tree{label: label(cursor.Node(), options), field: fieldName(cursor)}
+
+		parent := stack[len(stack)-1]
+		parent.children = append(parent.children, node)
+		stack = append(stack, node)
+
+		return true
+	}, func(cursor *ast.Cursor) bool {
+		stack = stack[:len(stack)-1]
+
+		return true
+	})
+
+	return stack[0].children[0]
+}
+
+// fieldName names the parent field holding the current node, such as
+// "value" or "arguments[1]".
+func fieldName(cursor *ast.Cursor) string {
+	if cursor.Parent() == nil {
+		return ""
+	}
+
+	name := strings.ToLower(cursor.Name()[:1]) + cursor.Name()[1:]
+
+	if name == "statemens" {
+		name = "statements"
+	}
+
+	if cursor.Index() >= 0 {
+		name += "[" + strconv.Itoa(cursor.Index()) + "]"
+	}
+
+	return name
+}
+
+// label returns the node type followed by the attributes that are not
+// child nodes, such as the operator of an infix expression.
+func label(node ast.Node, options Options) string {
+	parts := []string{strings.TrimPrefix(reflect.TypeOf(node).String(), "*ast.")}
+
+	switch node := node.(type) {
+	case *ast.Identifier:
+		parts = append(parts, node.Value)
+	case *ast.IntegerLiteral:
+		parts = append(parts, node.Token.Literal)
+	case *ast.Boolean:
+		parts = append(parts, node.Token.Literal)
+	case *ast.StringLiteral:
+		parts = append(parts, strconv.Quote(node.Value))
+	case *ast.TemplateString:
+		parts = append(parts, strconv.Quote(node.Value))
+	case *ast.PrefixExpression:
+		parts = append(parts, node.Operator)
+	case *ast.InfixExpression:
+		parts = append(parts, node.Operator)
+	case *ast.FunctionLiteral:
+		if node.IsGenerator {
+			parts = append(parts, "generator")
+		}
+		if node.IsArrow {
+			parts = append(parts, "arrow")
+		}
+	case *ast.Parameter:
+		if node.Rest {
+			parts = append(parts, "rest")
+		}
+	case *ast.CallExpression:
+		if node.Piped {
+			parts = append(parts, "piped")
+		}
+	case *ast.TypeName:
+		parts = append(parts, node.Name)
+	}
+
+	if tok, ok := nodeToken(node); ok && options.Positions && tok.Line > 0 {
+		parts = append(parts, fmt.Sprintf("@%d:%d", tok.Line, tok.Column))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+func nodeToken(node ast.Node) (token.Token, bool) {
+	field := reflect.ValueOf(node).Elem().FieldByName("Token")
+
+	if !field.IsValid() {
+		return token.Token{}, false
+	}
+
+	tok, ok := field.Interface().(token.Token)
+
+	return tok, ok
+}
+
+// SExpr renders the tree rooted at node as an S-expression with one child
+// per line, indented by two spaces per level:
+//
+//	(InfixExpression +
+//	  (IntegerLiteral 1)
+//	  (IntegerLiteral 2))
+//
+// A child in an optional field that could pass for one of its siblings,
+// such as the rest of a pattern or the bounds of a slice, is wrapped in the
+// name of the field: (rest (Identifier r)).
+func SExpr(node ast.Node, options Options) string {
+	var out bytes.Buffer
+
+	writeSExpr(&out, buildTree(node, options), 0)
+	out.WriteString("\n")
+
+	return out.String()
+}
+
+// markedFields are the fields SExpr names; see there.
+var markedFields = map[string]bool{
+	"rest": true, "low": true, "high": true, "step": true,
+	"catch": true, "finally": true, "enum": true,
+}
+
+func writeSExpr(out *bytes.Buffer, node *tree, depth int) {
+	out.WriteString("(" + node.label)
+
+	for _, child := range node.children {
+		out.WriteString("\n" + strings.Repeat("  ", depth+1))
+
+		if markedFields[child.field] {
+			out.WriteString("(" + child.field + " ")
+			writeSExpr(out, child, depth+1)
+			out.WriteString(")")
+		} else {
+			writeSExpr(out, child, depth+1)
+		}
+	}
+
+	out.WriteString(")")
+}
+
+// DOT renders the tree rooted at node as a Graphviz digraph. Edges are
+// labelled with the field that holds the child.
+func DOT(node ast.Node, options Options) string {
+	var out bytes.Buffer
+
+	out.WriteString("digraph AST {\n")
+	out.WriteString("  node [shape=box, fontname=monospace];\n")
+
+	count := 0
+	var writeNode func(node *tree)
+
+	writeNode = func(node *tree) {
+		id := count
+		count++
+
+		fmt.Fprintf(&out, "  n%d [label=%s];\n", id, dotString(node.label))
+
+		for _, child := range node.children {
+			fmt.Fprintf(&out, "  n%d -> n%d [label=%s];\n", id, count, dotString(child.field))
+			writeNode(child)
+		}
+	}
+
+	writeNode(buildTree(node, options))
+	out.WriteString("}\n")
+
+	return out.String()
+}
+
+var dotEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func dotString(s string) string {
+	return `"` + dotEscaper.Replace(s) + `"`
+}
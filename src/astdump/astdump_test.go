@@ -0,0 +1,150 @@
+package astdump
+
+import (
+	"monkey/ast"
+	"monkey/lexer"
+	"monkey/parser"
+	"testing"
+)
+
+func parse(t *testing.T, input string) *ast.Program {
+	t.Helper()
+
+	newParser := parser.NewParser(lexer.NewLexer(input))
+	program := newParser.ParseProgram()
+
+	if errors := newParser.Errors(); len(errors) > 0 {
+		t.Fatalf("parser errors: %v", errors)
+	}
+
+	return program
+}
+
+func TestSExpr(t *testing.T) {
+	tests := []struct {
+		input     string
+		positions bool
+		expected  string
+	}{
+		{
+			"-a + b * c",
+			false,
+			`(Program
+  (ExpressionStatement
+    (InfixExpression +
+      (PrefixExpression -
+        (Identifier a))
+      (InfixExpression *
+        (Identifier b)
+        (Identifier c)))))
+`,
+		},
+		{
+			`let f = fn*(...xs) { yield "x" };`,
+			false,
+			`(Program
+  (LetStatement
+    (Identifier f)
+    (FunctionLiteral generator
+      (Parameter rest
+        (Identifier xs))
+      (BlockStatement
+        (ExpressionStatement
+          (YieldExpression
+            (StringLiteral "x")))))))
+`,
+		},
+		{
+			"let n: [int] = [];",
+			false,
+			`(Program
+  (LetStatement
+    (Identifier n)
+    (ArrayType
+      (TypeName int))
+    (ArrayLiteral)))
+`,
+		},
+		{
+			"x |> f\n  |> g",
+			true,
+			`(Program
+  (ExpressionStatement @1:1
+    (CallExpression piped @2:3
+      (Identifier g @2:6)
+      (CallExpression piped @1:3
+        (Identifier f @1:6)
+        (Identifier x @1:1)))))
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		got := SExpr(parse(t, tt.input), Options{Positions: tt.positions})
+
+		if got != tt.expected {
+			t.Errorf("wrong S-expression for %q.\nexpected:\n%s\ngot:\n%s", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestSExprTellsOptionalFieldsApart(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let [a, r] = x;", `(Program
+  (LetStatement
+    (ArrayPattern
+      (Identifier a)
+      (Identifier r))
+    (Identifier x)))
+`},
+		{"let [a, ...r] = x;", `(Program
+  (LetStatement
+    (ArrayPattern
+      (Identifier a)
+      (rest (Identifier r)))
+    (Identifier x)))
+`},
+		{"x[:1:2];", `(Program
+  (ExpressionStatement
+    (SliceExpression
+      (Identifier x)
+      (high (IntegerLiteral 1))
+      (step (IntegerLiteral 2)))))
+`},
+	}
+
+	for _, tt := range tests {
+		got := SExpr(parse(t, tt.input), Options{})
+
+		if got != tt.expected {
+			t.Errorf("wrong S-expression for %q.\nexpected:\n%s\ngot:\n%s", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestDOT(t *testing.T) {
+	got := DOT(parse(t, `f(1, "a\"b")`), Options{Positions: true})
+
+	expected := `digraph AST {
+  node [shape=box, fontname=monospace];
+  n0 [label="Program"];
+  n0 -> n1 [label="statements[0]"];
+  n1 [label="ExpressionStatement @1:1"];
+  n1 -> n2 [label="expression"];
+  n2 [label="CallExpression @1:2"];
+  n2 -> n3 [label="function"];
+  n3 [label="Identifier f @1:1"];
+  n2 -> n4 [label="arguments[0]"];
+  n4 [label="IntegerLiteral 1 @1:3"];
+  n2 -> n5 [label="arguments[1]"];
+  n5 [label="StringLiteral \"a\\\"b\" @1:6"];
+}
+`
+
+	if got != expected {
+		t.Errorf("wrong DOT output.\nexpected:\n%s\ngot:\n%s", expected, got)
+	}
+}
@@ -0,0 +1,327 @@
+// Package astjson converts syntax trees to and from JSON.
+//
+// Every node becomes an object whose "type" field names the node type,
+// followed by its fields in declaration order under lower camel case
+// names. Tokens, including their positions, are kept in a "token" field:
+//
+//	{"type":"Identifier","token":{"type":"IDENT","literal":"x","line":1,"column":5},"value":"x"}
+//
+// Missing fields and null decode to the zero value of the field.
+package astjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"monkey/ast"
+	"monkey/token"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+var (
+	nodeType  = reflect.TypeOf((*ast.Node)(nil)).Elem()
+	tokenType = reflect.TypeOf(token.Token{})
+)
+
+// nodeTypes maps the name in the "type" field to the node type it decodes to.
+var nodeTypes = map[string]reflect.Type{}
+
+func init() {
+	for _, node := range []ast.Node{
+		&ast.Program{},
+		&ast.LetStatement{}, &ast.ReturnStatement{}, &ast.ExpressionStatement{}, &ast.BlockStatement{},
+		&ast.ImportStatement{}, &ast.ExportStatement{}, &ast.ThrowStatement{}, &ast.TryStatement{},
+		&ast.DeferStatement{}, &ast.SelectStatement{}, &ast.SelectCase{}, &ast.StructStatement{},
+		&ast.EnumStatement{}, &ast.EnumVariant{},
+		&ast.Identifier{}, &ast.IntegerLiteral{}, &ast.Boolean{}, &ast.StringLiteral{},
+		&ast.TemplateLiteral{}, &ast.TemplateString{}, &ast.PrefixExpression{}, &ast.InfixExpression{},
+		&ast.IfExpression{}, &ast.FunctionLiteral{}, &ast.Parameter{}, &ast.CallExpression{}, &ast.SpreadExpression{},
+		&ast.NamedArgument{}, &ast.MemberExpression{}, &ast.SpawnExpression{}, &ast.YieldExpression{},
+		&ast.ArrayLiteral{}, &ast.IndexExpression{}, &ast.SliceExpression{}, &ast.HashLiteral{},
+		&ast.MatchExpression{}, &ast.MatchArm{},
+		&ast.VariantPattern{}, &ast.LiteralPattern{}, &ast.ArrayPattern{}, &ast.HashPattern{},
+		&ast.TypeName{}, &ast.ArrayType{}, &ast.HashType{}, &ast.FunctionType{},
+	} {
+		nodeTypes[reflect.TypeOf(node).Elem().Name()] = reflect.TypeOf(node).Elem()
+	}
+}
+
+// fieldNames overrides the JSON name of fields whose Go name would leak
+// into the format or clash with the "type" of the node.
+var fieldNames = map[string]string{
+	"Statemens": "statements",
+	"Type":      "annotation",
+}
+
+type jsonToken struct {
+	Type    token.TokenType `json:"type"`
+	Literal string          `json:"literal"`
+	Line    int             `json:"line"`
+	Column  int             `json:"column"`
+}
+
+// Encode returns the JSON encoding of the tree rooted at node.
+func Encode(node ast.Node) ([]byte, error) {
+	return encodeValue(reflect.ValueOf(&node).Elem())
+}
+
+// Decode rebuilds the tree encoded in data.
+func Decode(data []byte) (ast.Node, error) {
+	return decodeNode(data)
+}
+
+// DecodeProgram rebuilds a program encoded in data.
+func DecodeProgram(data []byte) (*ast.Program, error) {
+	node, err := decodeNode(data)
+
+	if err != nil {
+		return nil, err
+	}
+
+	program, ok := node.(*ast.Program)
+
+	if !ok {
+		return nil, fmt.Errorf("expected a Program, got %s", typeName(node))
+	}
+
+	return program, nil
+}
+
+func encodeValue(value reflect.Value) ([]byte, error) {
+	switch {
+	case value.Type() == tokenType:
+		tok := value.Interface().(token.Token)
+
+		return json.Marshal(jsonToken{Type: tok.Type, Literal: tok.Literal, Line: tok.Line, Column: tok.Column})
+
+	case value.Kind() == reflect.Interface || value.Kind() == reflect.Ptr:
+		if value.IsNil() {
+			return []byte("null"), nil
+		}
+
+		if value.Kind() == reflect.Interface {
+			value = value.Elem()
+		}
+
+		if !value.Type().Implements(nodeType) {
+			return encodeStruct(value.Elem(), "")
+		}
+
+		name := value.Elem().Type().Name()
+
+		if nodeTypes[name] != value.Elem().Type() {
+			return nil, fmt.Errorf("cannot encode node type %s", value.Type())
+		}
+
+		return encodeStruct(value.Elem(), name)
+
+	case value.Kind() == reflect.Slice:
+		if value.IsNil() {
+			return []byte("null"), nil
+		}
+
+		var out bytes.Buffer
+		out.WriteString("[")
+
+		for i := 0; i < value.Len(); i++ {
+			element, err := encodeValue(value.Index(i))
+
+			if err != nil {
+				return nil, err
+			}
+
+			if i > 0 {
+				out.WriteString(",")
+			}
+			out.Write(element)
+		}
+
+		out.WriteString("]")
+
+		return out.Bytes(), nil
+
+	default:
+		return json.Marshal(value.Interface())
+	}
+}
+
+// encodeStruct writes the fields of a node, or of a pair such as
+// ast.HashPair when name is empty.
+func encodeStruct(value reflect.Value, name string) ([]byte, error) {
+	var out bytes.Buffer
+	out.WriteString("{")
+
+	if name != "" {
+		out.WriteString(`"type":`)
+		out.Write(mustMarshal(name))
+	}
+
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+
+		// the shorthand {name} of a hash pattern shares one identifier
+		// for key and value, so the value is left out
+		if pair, ok := value.Addr().Interface().(*ast.HashPatternPair); ok && field.Name == "Value" && pair.Value == ast.Pattern(pair.Key) {
+			continue
+		}
+
+		encoded, err := encodeValue(value.Field(i))
+
+		if err != nil {
+			return nil, err
+		}
+
+		if out.Len() > 1 {
+			out.WriteString(",")
+		}
+		out.Write(mustMarshal(jsonName(field.Name)))
+		out.WriteString(":")
+		out.Write(encoded)
+	}
+
+	out.WriteString("}")
+
+	return out.Bytes(), nil
+}
+
+func decodeNode(data []byte) (ast.Node, error) {
+	var fields map[string]json.RawMessage
+
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	if fields == nil {
+		return nil, nil
+	}
+
+	var name string
+
+	if err := json.Unmarshal(fields["type"], &name); err != nil {
+		return nil, fmt.Errorf("invalid node type %s", fields["type"])
+	}
+
+	structType, ok := nodeTypes[name]
+
+	if !ok {
+		return nil, fmt.Errorf("unknown node type %q", name)
+	}
+
+	node := reflect.New(structType)
+
+	if err := decodeStruct(node.Elem(), fields); err != nil {
+		return nil, fmt.Errorf("%s.%v", name, err)
+	}
+
+	return node.Interface().(ast.Node), nil
+}
+
+func decodeStruct(value reflect.Value, fields map[string]json.RawMessage) error {
+	for i := 0; i < value.NumField(); i++ {
+		name := value.Type().Field(i).Name
+		data, ok := fields[jsonName(name)]
+
+		if !ok {
+			continue
+		}
+
+		if err := decodeValue(value.Field(i), data); err != nil {
+			return fmt.Errorf("%s: %v", jsonName(name), err)
+		}
+	}
+
+	if pair, ok := value.Addr().Interface().(*ast.HashPatternPair); ok && pair.Value == nil {
+		pair.Value = pair.Key
+	}
+
+	return nil
+}
+
+func decodeValue(value reflect.Value, data []byte) error {
+	switch {
+	case value.Type() == tokenType:
+		var tok jsonToken
+
+		if err := json.Unmarshal(data, &tok); err != nil {
+			return err
+		}
+
+		value.Set(reflect.ValueOf(token.Token{Type: tok.Type, Literal: tok.Literal, Line: tok.Line, Column: tok.Column}))
+
+	case value.Type().Implements(nodeType):
+		node, err := decodeNode(data)
+
+		if err != nil || node == nil {
+			return err
+		}
+
+		if !reflect.TypeOf(node).AssignableTo(value.Type()) {
+			return fmt.Errorf("expected %s, got %s", typeName(value.Type()), typeName(node))
+		}
+
+		value.Set(reflect.ValueOf(node))
+
+	case value.Kind() == reflect.Ptr:
+		var fields map[string]json.RawMessage
+
+		if err := json.Unmarshal(data, &fields); err != nil || fields == nil {
+			return err
+		}
+
+		value.Set(reflect.New(value.Type().Elem()))
+
+		return decodeStruct(value.Elem(), fields)
+
+	case value.Kind() == reflect.Slice:
+		var elements []json.RawMessage
+
+		if err := json.Unmarshal(data, &elements); err != nil || elements == nil {
+			return err
+		}
+
+		value.Set(reflect.MakeSlice(value.Type(), len(elements), len(elements)))
+
+		for i, element := range elements {
+			if err := decodeValue(value.Index(i), element); err != nil {
+				return fmt.Errorf("[%d]: %v", i, err)
+			}
+		}
+
+	default:
+		return json.Unmarshal(data, value.Addr().Interface())
+	}
+
+	return nil
+}
+
+// jsonName turns a Go field name such as ReturnValue into returnValue.
+func jsonName(name string) string {
+	if renamed, ok := fieldNames[name]; ok {
+		return renamed
+	}
+
+	return string(unicode.ToLower(rune(name[0]))) + name[1:]
+}
+
+// typeName returns the unqualified name of a node or node type.
+func typeName(node interface{}) string {
+	t, ok := node.(reflect.Type)
+
+	if !ok {
+		t = reflect.TypeOf(node)
+	}
+
+	return strings.TrimPrefix(strings.TrimPrefix(t.String(), "*"), "ast.")
+}
+
+func mustMarshal(value interface{}) []byte {
+	encoded, err := json.Marshal(value)
+
+	if err != nil {
+		panic(err)
+	}
+
+	return encoded
+}
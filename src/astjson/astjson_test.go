@@ -0,0 +1,163 @@
+package astjson
+
+import (
+	"monkey/ast"
+	"monkey/lexer"
+	"monkey/parser"
+	"strings"
+	"testing"
+)
+
+func parse(t *testing.T, input string) *ast.Program {
+	t.Helper()
+
+	newParser := parser.NewParser(lexer.NewLexer(input))
+	program := newParser.ParseProgram()
+
+	if errors := newParser.Errors(); len(errors) > 0 {
+		t.Fatalf("parser errors: %v", errors)
+	}
+
+	return program
+}
+
+func TestRoundTrip(t *testing.T) {
+	input := `
+let a = -1 + 2 * 3 != 4;
+let [b, ...c] = [true, false];
+let {d, e: [f], ...g} = {"d": 1};
+import "strings" as str;
+export let h = "text";
+throw error;
+try { risky() } catch (err) { log(err) } finally { done() }
+let gen = fn*(x, [y] = [1], ...z) { defer close(); yield x; yield; return z; };
+let arrow = (p, q) => p + q;
+let typed: {string: [int]} = fn(r: int = 1, ...s: [bool]) -> fn(int) -> any { t };
+select { case v = recv(ch) { v } default { 0 } }
+struct Point { x, y }
+enum Shape { Circle(radius), Square }
+match shape { Circle(r) => r, Shape.Square => { 0 }, 5 => 1, [m] => m, {n} => n };
+f(1, ...rest, named: 2);
+` + "`sum ${a + b} done`;" + `
+point.x;
+spawn worker(1);
+list[0];
+list[1:2:3];
+list[:];
+if (a < b) { a } else if (b) { b } else { c }
+[1] |> append(2) |> len;
+`
+
+	program := parse(t, input)
+
+	encoded, err := Encode(program)
+
+	if err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+
+	decoded, err := DecodeProgram(encoded)
+
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+
+	if !ast.Equal(decoded, program, ast.EqualOptions{}) {
+		t.Errorf("decoded program differs.\nexpected=%q\ngot=     %q", program.String(), decoded.String())
+	}
+
+	// encoding the decoded tree again must give the same bytes, which also
+	// shows that tokens and positions survived
+	reencoded, err := Encode(decoded)
+
+	if err != nil {
+		t.Fatalf("Encode of decoded program failed: %v", err)
+	}
+
+	if string(reencoded) != string(encoded) {
+		t.Errorf("encoding is not stable.\nfirst= %s\nsecond=%s", encoded, reencoded)
+	}
+
+	types := map[string]bool{}
+	ast.Inspect(decoded, func(node ast.Node) bool {
+		if node != nil {
+			types[typeName(node)] = true
+		}
+
+		return true
+	})
+
+	for name := range nodeTypes {
+		if !types[name] {
+			t.Errorf("node type %s is missing from the round trip input", name)
+		}
+	}
+}
+
+func TestEncode(t *testing.T) {
+	encoded, err := Encode(parse(t, "return x;"))
+
+	if err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+
+	expected := `{"type":"Program","statements":[{"type":"ReturnStatement",` +
+		`"token":{"type":"RETURN","literal":"return","line":1,"column":1},` +
+		`"returnValue":{"type":"Identifier","token":{"type":"IDENT","literal":"x","line":1,"column":8},"value":"x"}}]}`
+
+	if string(encoded) != expected {
+		t.Errorf("wrong encoding.\nexpected=%s\ngot=     %s", expected, encoded)
+	}
+}
+
+func TestDecodeKeepsShorthandHashPatterns(t *testing.T) {
+	encoded, err := Encode(parse(t, "let {a, b: c} = h;"))
+
+	if err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+
+	program, err := DecodeProgram(encoded)
+
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+
+	pairs := program.Statemens[0].(*ast.LetStatement).Pattern.(*ast.HashPattern).Pairs
+
+	if pairs[0].Value != ast.Pattern(pairs[0].Key) {
+		t.Errorf("expected shorthand pair to share key and value")
+	}
+
+	if pairs[1].Value == ast.Pattern(pairs[1].Key) {
+		t.Errorf("expected b: c to have its own value")
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`{"type":"Nope"}`, `unknown node type "Nope"`},
+		{`{"value":"x"}`, "invalid node type"},
+		{`{"type":"Program","statements":[{"type":"Identifier","value":"x"}]}`,
+			"Program.statements: [0]: expected Statement, got Identifier"},
+		{`{"type":"ReturnStatement","returnValue":{"type":"Bogus"}}`,
+			`ReturnStatement.returnValue: unknown node type "Bogus"`},
+		{`[1, 2]`, "cannot unmarshal array"},
+	}
+
+	for _, tt := range tests {
+		_, err := Decode([]byte(tt.input))
+
+		if err == nil || !strings.Contains(err.Error(), tt.expected) {
+			t.Errorf("wrong error for %s. expected to contain %q, got=%v", tt.input, tt.expected, err)
+		}
+	}
+
+	if _, err := DecodeProgram([]byte(`{"type":"Identifier","value":"x"}`)); err == nil ||
+		err.Error() != "expected a Program, got Identifier" {
+		t.Errorf("wrong DecodeProgram error. got=%v", err)
+	}
+}
@@ -0,0 +1,22 @@
+package checker
+
+import (
+	"fmt"
+	"monkey/token"
+)
+
+// Warning is a problem found by a static check, at the position of the
+// token it refers to.
+type Warning struct {
+	Line    int
+	Column  int
+	Message string
+}
+
+func (warning Warning) String() string {
+	return fmt.Sprintf("%d:%d: %s", warning.Line, warning.Column, warning.Message)
+}
+
+func newWarning(tok token.Token, format string, args ...interface{}) Warning {
+	return Warning{Line: tok.Line, Column: tok.Column, Message: fmt.Sprintf(format, args...)}
+}
@@ -0,0 +1,150 @@
+package checker
+
+import (
+	"monkey/ast"
+	"strings"
+)
+
+// CheckMatches warns about match expressions over enum values that do not
+// cover every variant of the enum and have no catch-all arm, and about arms
+// naming variants that do not exist or binding the wrong number of fields.
+// The enum a match is over is inferred from the variants its arms name.
+func CheckMatches(program *ast.Program) []Warning {
+	enums := map[string]*ast.EnumStatement{}
+	variants := map[string][]*ast.EnumStatement{}
+
+	ast.Inspect(program, func(node ast.Node) bool {
+		if enum, ok := node.(*ast.EnumStatement); ok {
+			enums[enum.Name.Value] = enum
+
+			for _, variant := range enum.Variants {
+				variants[variant.Name.Value] = append(variants[variant.Name.Value], enum)
+			}
+		}
+
+		return true
+	})
+
+	checker := &matchChecker{enums: enums, variants: variants, warnings: []Warning{}}
+
+	ast.Inspect(program, func(node ast.Node) bool {
+		if match, ok := node.(*ast.MatchExpression); ok {
+			checker.checkMatch(match)
+		}
+
+		return true
+	})
+
+	return checker.warnings
+}
+
+type matchChecker struct {
+	enums    map[string]*ast.EnumStatement
+	variants map[string][]*ast.EnumStatement
+	warnings []Warning
+}
+
+func (checker *matchChecker) checkMatch(match *ast.MatchExpression) {
+	var enum *ast.EnumStatement
+	covered := map[string]bool{}
+	catchAll := false
+
+	for _, arm := range match.Arms {
+		switch pattern := arm.Pattern.(type) {
+		case *ast.Identifier:
+			catchAll = true
+
+		case *ast.VariantPattern:
+			armEnum, variant := checker.lookup(pattern)
+
+			if variant == nil {
+				continue
+			}
+
+			if enum != nil && armEnum != enum {
+				checker.warnings = append(checker.warnings, newWarning(pattern.Token,
+					"match mixes variants of %s and %s", enum.Name.Value, armEnum.Name.Value))
+
+				return
+			}
+
+			enum = armEnum
+
+			if len(pattern.Bindings) > 0 && len(pattern.Bindings) != len(variant.Fields) {
+				checker.warnings = append(checker.warnings, newWarning(pattern.Token,
+					"variant %s.%s has %d fields, pattern binds %d",
+					enum.Name.Value, variant.Name.Value, len(variant.Fields), len(pattern.Bindings)))
+			}
+
+			if irrefutable(pattern.Bindings) {
+				covered[variant.Name.Value] = true
+			}
+		}
+	}
+
+	if enum == nil || catchAll {
+		return
+	}
+
+	missing := []string{}
+
+	for _, variant := range enum.Variants {
+		if !covered[variant.Name.Value] {
+			missing = append(missing, variant.Name.Value)
+		}
+	}
+
+	if len(missing) > 0 {
+		checker.warnings = append(checker.warnings, newWarning(match.Token,
+			"non-exhaustive match on %s: missing %s", enum.Name.Value, strings.Join(missing, ", ")))
+	}
+}
+
+// lookup finds the enum and variant a pattern refers to, warning about
+// unknown names. Unqualified variants declared by several enums are
+// ambiguous and skipped.
+func (checker *matchChecker) lookup(pattern *ast.VariantPattern) (*ast.EnumStatement, *ast.EnumVariant) {
+	name := pattern.Variant.Value
+
+	if pattern.Enum != nil {
+		enum, ok := checker.enums[pattern.Enum.Value]
+
+		if !ok {
+			checker.warnings = append(checker.warnings, newWarning(pattern.Token, "unknown enum %s", pattern.Enum.Value))
+
+			return nil, nil
+		}
+
+		variant := enum.Variant(name)
+
+		if variant == nil {
+			checker.warnings = append(checker.warnings, newWarning(pattern.Variant.Token,
+				"enum %s has no variant %s", enum.Name.Value, name))
+		}
+
+		return enum, variant
+	}
+
+	switch candidates := checker.variants[name]; len(candidates) {
+	case 0:
+		checker.warnings = append(checker.warnings, newWarning(pattern.Token, "unknown variant %s", name))
+
+		return nil, nil
+	case 1:
+		return candidates[0], candidates[0].Variant(name)
+	default:
+		return nil, nil
+	}
+}
+
+// irrefutable reports whether the payload bindings of a variant pattern
+// match every value, so that the arm covers the whole variant.
+func irrefutable(bindings []ast.Pattern) bool {
+	for _, binding := range bindings {
+		if _, ok := binding.(*ast.Identifier); !ok {
+			return false
+		}
+	}
+
+	return true
+}
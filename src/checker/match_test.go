@@ -0,0 +1,96 @@
+package checker
+
+import (
+	"monkey/lexer"
+	"monkey/parser"
+	"testing"
+)
+
+func checkMatches(t *testing.T, input string) []Warning {
+	t.Helper()
+
+	newParser := parser.NewParser(lexer.NewLexer(input))
+	program := newParser.ParseProgram()
+
+	if errors := newParser.Errors(); len(errors) > 0 {
+		t.Fatalf("parser errors for %q: %v", input, errors)
+	}
+
+	return CheckMatches(program)
+}
+
+func TestExhaustiveMatches(t *testing.T) {
+	tests := []string{
+		`enum Status { Pending, Done(result), Failed(err) }
+match s { Pending => 0, Done(r) => r, Failed(e) => -1 }`,
+		`enum Status { Pending, Done(result), Failed(err) }
+match s { Done(r) => r, _ => 0 }`,
+		`enum Status { Pending, Done(result) }
+match s { Status.Pending => 0, Status.Done(r) => r }`,
+		`enum Status { Pending, Done(result) }
+match s { Done(r) => r, other => 0 }`,
+		`match n { 1 => "one", 2 => "two" }`,
+	}
+
+	for _, input := range tests {
+		if warnings := checkMatches(t, input); len(warnings) != 0 {
+			t.Errorf("expected no warnings for %q. got=%v", input, warnings)
+		}
+	}
+}
+
+func TestNonExhaustiveMatches(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{
+			`enum Status { Pending, Done(result), Failed(err) }
+match s { Pending => 0, Done(r) => r }`,
+			[]string{"2:1: non-exhaustive match on Status: missing Failed"},
+		},
+		{
+			`enum Status { Pending, Done(result), Failed(err) }
+let f = fn(s) {
+  match s { Done(1) => 1, Done(r) => r }
+};`,
+			[]string{"3:3: non-exhaustive match on Status: missing Pending, Failed"},
+		},
+		{
+			`enum Status { Pending, Done(result) }
+match s { Pending => 0, Done(1) => 1 }`,
+			[]string{"2:1: non-exhaustive match on Status: missing Done"},
+		},
+		{
+			`enum Status { Pending, Done(result) }
+match s { Pending => 0, Done(a, b) => 1 }`,
+			[]string{"2:25: variant Status.Done has 1 fields, pattern binds 2"},
+		},
+		{
+			`enum Status { Pending }
+match s { Pending => 0, Unknown => 1, Status.Other => 2, Color.Red => 3 }`,
+			[]string{"2:25: unknown variant Unknown", "2:46: enum Status has no variant Other", "2:58: unknown enum Color"},
+		},
+		{
+			`enum Status { Pending }
+enum Color { Red }
+match s { Pending => 0, Red => 1 }`,
+			[]string{"3:25: match mixes variants of Status and Color"},
+		},
+	}
+
+	for _, tt := range tests {
+		warnings := checkMatches(t, tt.input)
+
+		if len(warnings) != len(tt.expected) {
+			t.Errorf("wrong number of warnings for %q. expected=%v, got=%v", tt.input, tt.expected, warnings)
+			continue
+		}
+
+		for i, warning := range warnings {
+			if warning.String() != tt.expected[i] {
+				t.Errorf("warning[%d] wrong. expected=%q, got=%q", i, tt.expected[i], warning.String())
+			}
+		}
+	}
+}
@@ -0,0 +1,1022 @@
+package checker
+
+import (
+	"fmt"
+	"monkey/ast"
+	"monkey/resolver"
+	"monkey/token"
+	"reflect"
+	"sort"
+)
+
+// Types is what CheckTypes found out about a program.
+type Types struct {
+	Bindings map[*ast.Identifier]Type // the type of every name bound by a let or a parameter
+	Warnings []Warning
+}
+
+// CheckTypes infers the types of the values in program, Hindley-Milner
+// style, and warns about operations that would fail at run time and about
+// values that do not match their type annotations.
+//
+// Names bound by let to a function are generalized, so that fn(x) { x }
+// can be used with integers and strings alike. Where the types of two
+// values meet, such as the branches of an if or the elements of an array,
+// and do not agree, the result is Any rather than a warning, so dynamic
+// code is accepted. So are names the checker knows nothing about, such as
+// builtins, imports, struct and enum constructors, and uses of a let
+// binding before the let is reached.
+func CheckTypes(program *ast.Program) *Types {
+	checker := &typeChecker{
+		resolution: resolver.Resolve(program),
+		bindings:   map[*resolver.Declaration]Type{},
+		types:      &Types{Bindings: map[*ast.Identifier]Type{}, Warnings: []Warning{}},
+	}
+
+	checker.statements(program.Statemens)
+
+	warnings := checker.types.Warnings
+
+	sort.SliceStable(warnings, func(i, j int) bool {
+		if warnings[i].Line != warnings[j].Line {
+			return warnings[i].Line < warnings[j].Line
+		}
+
+		return warnings[i].Column < warnings[j].Column
+	})
+
+	return checker.types
+}
+
+type typeChecker struct {
+	resolution *resolver.Resolution
+	bindings   map[*resolver.Declaration]Type
+	types      *Types
+	level      int      // the number of enclosing let values
+	undo       []func() // reverts the bindings of type variables; see try
+	functions  []*functionContext
+}
+
+// functionContext collects the return values of the function being checked.
+type functionContext struct {
+	expected Type // the annotated result type, or nil
+	returns  Type // the join of the returned values, or nil if there are none
+}
+
+func (checker *typeChecker) warn(tok token.Token, format string, args ...interface{}) {
+	checker.types.Warnings = append(checker.types.Warnings, newWarning(tok, format, args...))
+}
+
+func (checker *typeChecker) newVariable() *variable {
+	return &variable{level: checker.level}
+}
+
+// try runs f and undoes the type variable bindings it made if it fails.
+func (checker *typeChecker) try(f func() bool) bool {
+	mark := len(checker.undo)
+
+	if f() {
+		return true
+	}
+
+	for len(checker.undo) > mark {
+		checker.undo[len(checker.undo)-1]()
+		checker.undo = checker.undo[:len(checker.undo)-1]
+	}
+
+	return false
+}
+
+// compatible unifies a and b, binding type variables so that they become
+// the same type, and reports whether that is possible. Nothing is bound
+// when it is not.
+func (checker *typeChecker) compatible(a, b Type) bool {
+	return checker.try(func() bool { return checker.unify(a, b) })
+}
+
+func (checker *typeChecker) unify(a, b Type) bool {
+	a, b = prune(a), prune(b)
+
+	if a == b || a == Any || b == Any {
+		return true
+	}
+
+	if v, ok := a.(*variable); ok {
+		return checker.bind(v, b)
+	}
+
+	if v, ok := b.(*variable); ok {
+		return checker.bind(v, a)
+	}
+
+	switch a := a.(type) {
+	case *Array:
+		b, ok := b.(*Array)
+
+		return ok && checker.unify(a.Element, b.Element)
+
+	case *Hash:
+		b, ok := b.(*Hash)
+
+		return ok && checker.unify(a.Key, b.Key) && checker.unify(a.Value, b.Value)
+
+	case *Function:
+		b, ok := b.(*Function)
+
+		if !ok {
+			return false
+		}
+
+		// The number of parameters is only checked where a function is
+		// called, as a function with defaults can stand in for one with
+		// fewer parameters.
+		for i := 0; i < min(len(a.Parameters), len(b.Parameters)); i++ {
+			if !checker.unify(a.Parameters[i], b.Parameters[i]) {
+				return false
+			}
+		}
+
+		if a.Rest != nil && b.Rest != nil && !checker.unify(a.Rest, b.Rest) {
+			return false
+		}
+
+		return checker.unify(a.Result, b.Result)
+	}
+
+	return false
+}
+
+// bind makes v stand for t, unless v is an operand of + and t can be
+// neither an int nor a string.
+func (checker *typeChecker) bind(v *variable, t Type) bool {
+	// A function applied to itself has no finite type; its parameter is
+	// left unknown rather than rejected.
+	if occurs(v, t) {
+		return true
+	}
+
+	if v.operand {
+		switch t := t.(type) {
+		case *variable:
+			checker.constrain(t)
+		case Basic:
+			if t != Int && t != String {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+
+	checker.lowerLevels(t, v.level)
+
+	v.instance = t
+	checker.undo = append(checker.undo, func() { v.instance = nil })
+
+	return true
+}
+
+// constrain marks v as an operand of +.
+func (checker *typeChecker) constrain(v *variable) {
+	if !v.operand {
+		v.operand = true
+		checker.undo = append(checker.undo, func() { v.operand = false })
+	}
+}
+
+func occurs(v *variable, t Type) bool {
+	switch t := prune(t).(type) {
+	case *variable:
+		return t == v
+	case *Array:
+		return occurs(v, t.Element)
+	case *Hash:
+		return occurs(v, t.Key) || occurs(v, t.Value)
+	case *Function:
+		for _, parameter := range t.Parameters {
+			if occurs(v, parameter) {
+				return true
+			}
+		}
+
+		return (t.Rest != nil && occurs(v, t.Rest)) || occurs(v, t.Result)
+	}
+
+	return false
+}
+
+// lowerLevels moves the type variables in t out to level, so that they are
+// not generalized as long as a variable of that level refers to them.
+func (checker *typeChecker) lowerLevels(t Type, level int) {
+	visit(t, func(v *variable) {
+		if v.level > level {
+			old := v.level
+			v.level = level
+			checker.undo = append(checker.undo, func() { v.level = old })
+		}
+	})
+}
+
+// visit calls f for every unbound type variable in t.
+func visit(t Type, f func(*variable)) {
+	switch t := prune(t).(type) {
+	case *variable:
+		f(t)
+	case *Array:
+		visit(t.Element, f)
+	case *Hash:
+		visit(t.Key, f)
+		visit(t.Value, f)
+	case *Function:
+		for _, parameter := range t.Parameters {
+			visit(parameter, f)
+		}
+
+		if t.Rest != nil {
+			visit(t.Rest, f)
+		}
+
+		visit(t.Result, f)
+	}
+}
+
+// generalize marks the type variables created inside the current let value
+// and not referred to from outside it as generic.
+func (checker *typeChecker) generalize(t Type) Type {
+	visit(t, func(v *variable) {
+		if v.level > checker.level {
+			v.level = generic
+		}
+	})
+
+	return t
+}
+
+// instantiate returns t with fresh type variables for its generic ones.
+func (checker *typeChecker) instantiate(t Type) Type {
+	fresh := map[*variable]*variable{}
+
+	var copy func(Type) Type
+	copy = func(t Type) Type {
+		switch t := prune(t).(type) {
+		case *variable:
+			if t.level != generic {
+				return t
+			}
+
+			if _, ok := fresh[t]; !ok {
+				fresh[t] = checker.newVariable()
+				fresh[t].operand = t.operand
+			}
+
+			return fresh[t]
+
+		case *Array:
+			return &Array{Element: copy(t.Element)}
+
+		case *Hash:
+			return &Hash{Key: copy(t.Key), Value: copy(t.Value)}
+
+		case *Function:
+			function := &Function{Parameters: []Type{}, Names: t.Names, Required: t.Required, Result: copy(t.Result)}
+
+			for _, parameter := range t.Parameters {
+				function.Parameters = append(function.Parameters, copy(parameter))
+			}
+
+			if t.Rest != nil {
+				function.Rest = copy(t.Rest)
+			}
+
+			return function
+
+		default:
+			return t
+		}
+	}
+
+	return copy(t)
+}
+
+// join returns the type of a value that is either of type a or b: their
+// unification if there is one and Any otherwise. A nil type stands for no
+// value at all, such as that of a block ending in a return.
+func (checker *typeChecker) join(a, b Type) Type {
+	switch {
+	case a == nil:
+		return b
+	case b == nil:
+		return a
+	case prune(a) == Any || prune(b) == Any || !checker.compatible(a, b):
+		return Any
+	default:
+		return a
+	}
+}
+
+// expect warns when got, the type of node, does not match want.
+func (checker *typeChecker) expect(node ast.Node, got, want Type, context string) {
+	if checker.compatible(got, want) {
+		return
+	}
+
+	types := describe(want, got)
+
+	if v, ok := prune(want).(*variable); ok && v.operand {
+		types[0] = "int or string"
+	}
+
+	message := fmt.Sprintf("expected %s, got %s", types[0], types[1])
+
+	if context != "" {
+		message = context + ": " + message
+	}
+
+	checker.warn(start(node), "%s", message)
+}
+
+// start returns the first token of node, which is not always the token the
+// node stores: that of an infix expression is its operator.
+func start(node ast.Node) token.Token {
+	var first token.Token
+
+	ast.Inspect(node, func(node ast.Node) bool {
+		if node == nil {
+			return false
+		}
+
+		if field := reflect.ValueOf(node).Elem().FieldByName("Token"); field.IsValid() {
+			tok := field.Interface().(token.Token)
+
+			if tok.Line > 0 && (first.Line == 0 || tok.Line < first.Line ||
+				tok.Line == first.Line && tok.Column < first.Column) {
+				first = tok
+			}
+		}
+
+		return true
+	})
+
+	return first
+}
+
+// annotation returns the type a type annotation stands for.
+func (checker *typeChecker) annotation(annotation ast.TypeExpression) Type {
+	switch annotation := annotation.(type) {
+	case *ast.TypeName:
+		if t, ok := basicTypes[annotation.Name]; ok {
+			return t
+		}
+
+		checker.warn(annotation.Token, "unknown type %s", annotation.Name)
+
+	case *ast.ArrayType:
+		return &Array{Element: checker.annotation(annotation.Element)}
+
+	case *ast.HashType:
+		return &Hash{Key: checker.annotation(annotation.Key), Value: checker.annotation(annotation.Value)}
+
+	case *ast.FunctionType:
+		function := &Function{Parameters: []Type{}, Required: len(annotation.Parameters), Result: checker.annotation(annotation.Result)}
+
+		for _, parameter := range annotation.Parameters {
+			function.Parameters = append(function.Parameters, checker.annotation(parameter))
+		}
+
+		return function
+	}
+
+	return Any
+}
+
+// statements checks a list of statements and returns the type of its
+// value, that of the last statement.
+func (checker *typeChecker) statements(statements []ast.Statement) Type {
+	var value Type = Any
+
+	for _, statement := range statements {
+		value = checker.statement(statement)
+	}
+
+	return value
+}
+
+func (checker *typeChecker) block(block *ast.BlockStatement) Type {
+	if block == nil {
+		return Any
+	}
+
+	return checker.statements(block.Statements)
+}
+
+// statement checks statement and returns the type of its value, which is
+// nil for statements that leave the block.
+func (checker *typeChecker) statement(statement ast.Statement) Type {
+	switch statement := statement.(type) {
+	case *ast.ExpressionStatement:
+		return checker.infer(statement.Expression)
+
+	case *ast.LetStatement:
+		checker.let(statement)
+
+	case *ast.ReturnStatement:
+		checker.returnStatement(statement)
+
+		return nil
+
+	case *ast.ThrowStatement:
+		checker.infer(statement.Value)
+
+		return nil
+
+	case *ast.ExportStatement:
+		checker.statement(statement.Declaration)
+
+	case *ast.TryStatement:
+		checker.block(statement.Block)
+
+		if statement.Catch != nil {
+			checker.block(statement.Catch)
+		}
+
+		if statement.Finally != nil {
+			checker.block(statement.Finally)
+		}
+
+	case *ast.DeferStatement:
+		checker.infer(statement.Call)
+
+	case *ast.SelectStatement:
+		for _, selectCase := range statement.Cases {
+			checker.infer(selectCase.Operation)
+			checker.block(selectCase.Body)
+		}
+
+		if statement.Default != nil {
+			checker.block(statement.Default)
+		}
+	}
+
+	return Any
+}
+
+func (checker *typeChecker) let(statement *ast.LetStatement) {
+	var annotation Type
+
+	if statement.Type != nil {
+		annotation = checker.annotation(statement.Type)
+	}
+
+	function, isFunction := statement.Value.(*ast.FunctionLiteral)
+
+	checker.level++
+
+	// A function can call itself through the name it is bound to.
+	var self Type
+
+	if isFunction && statement.Name != nil {
+		self = checker.newVariable()
+		checker.define(statement.Name, self)
+	}
+
+	value := checker.infer(statement.Value)
+
+	if self != nil {
+		checker.compatible(self, value)
+	}
+
+	checker.level--
+
+	if annotation != nil {
+		checker.expect(statement.Value, value, annotation, "")
+		value = annotation
+	} else if isFunction && !function.IsGenerator {
+		value = checker.generalize(value)
+	}
+
+	checker.pattern(statement.Target(), value)
+}
+
+func (checker *typeChecker) returnStatement(statement *ast.ReturnStatement) {
+	var value Type = Any
+
+	if statement.ReturnValue != nil {
+		value = checker.infer(statement.ReturnValue)
+	}
+
+	if len(checker.functions) == 0 {
+		return
+	}
+
+	context := checker.functions[len(checker.functions)-1]
+
+	if context.expected != nil {
+		checker.expect(statement.ReturnValue, value, context.expected, "")
+	} else {
+		context.returns = checker.join(context.returns, value)
+	}
+}
+
+// define records the type of the name a binding declares.
+func (checker *typeChecker) define(name *ast.Identifier, t Type) {
+	if declaration := checker.resolution.Declarations[name]; declaration != nil {
+		checker.bindings[declaration] = t
+		checker.types.Bindings[name] = t
+	}
+}
+
+// pattern binds the names in a binding or match pattern for a value of
+// type t. Elements of arrays and values of hashes get the element and
+// value types of t where t is known to be an array or a hash.
+func (checker *typeChecker) pattern(pattern ast.Pattern, t Type) {
+	switch pattern := pattern.(type) {
+	case *ast.Identifier:
+		checker.define(pattern, t)
+
+	case *ast.ArrayPattern:
+		var element Type = Any
+
+		if array, ok := prune(t).(*Array); ok {
+			element = array.Element
+		} else {
+			t = Any
+		}
+
+		for _, item := range pattern.Elements {
+			checker.pattern(item, element)
+		}
+
+		if pattern.Rest != nil {
+			checker.define(pattern.Rest, t)
+		}
+
+	case *ast.HashPattern:
+		var value Type = Any
+
+		if hash, ok := prune(t).(*Hash); ok {
+			value = hash.Value
+		} else {
+			t = Any
+		}
+
+		for _, pair := range pattern.Pairs {
+			checker.pattern(pair.Value, value)
+		}
+
+		if pattern.Rest != nil {
+			checker.define(pattern.Rest, t)
+		}
+
+	case *ast.VariantPattern:
+		for _, binding := range pattern.Bindings {
+			checker.pattern(binding, Any)
+		}
+	}
+}
+
+func (checker *typeChecker) infer(expression ast.Expression) Type {
+	switch expression := expression.(type) {
+	case *ast.IntegerLiteral:
+		return Int
+
+	case *ast.Boolean:
+		return Bool
+
+	case *ast.StringLiteral, *ast.TemplateString:
+		return String
+
+	case *ast.TemplateLiteral:
+		for _, part := range expression.Parts {
+			checker.infer(part)
+		}
+
+		return String
+
+	case *ast.Identifier:
+		if t, ok := checker.bindings[checker.resolution.Uses[expression]]; ok {
+			return checker.instantiate(t)
+		}
+
+	case *ast.PrefixExpression:
+		return checker.prefix(expression)
+
+	case *ast.InfixExpression:
+		return checker.infix(expression)
+
+	case *ast.IfExpression:
+		checker.infer(expression.Condition)
+
+		return checker.join(checker.block(expression.Consequence), checker.block(expression.Alternative))
+
+	case *ast.FunctionLiteral:
+		return checker.function(expression)
+
+	case *ast.CallExpression:
+		return checker.call(expression)
+
+	case *ast.ArrayLiteral:
+		var element Type
+
+		for _, e := range expression.Elements {
+			element = checker.join(element, checker.infer(e))
+		}
+
+		if element == nil {
+			element = checker.newVariable()
+		}
+
+		return &Array{Element: element}
+
+	case *ast.HashLiteral:
+		return checker.hash(expression)
+
+	case *ast.IndexExpression:
+		return checker.index(expression)
+
+	case *ast.SliceExpression:
+		left := checker.infer(expression.Left)
+
+		for _, bound := range []ast.Expression{expression.Low, expression.High, expression.Step} {
+			if bound != nil {
+				checker.expect(bound, checker.infer(bound), Int, "")
+			}
+		}
+
+		switch t := prune(left).(type) {
+		case *Array:
+			return t
+		case Basic:
+			if t == String {
+				return String
+			}
+		}
+
+	case *ast.MatchExpression:
+		subject := checker.infer(expression.Subject)
+
+		var value Type
+
+		for _, arm := range expression.Arms {
+			checker.pattern(arm.Pattern, subject)
+			value = checker.join(value, checker.block(arm.Body))
+		}
+
+		if value != nil {
+			return value
+		}
+
+	case *ast.MemberExpression:
+		checker.infer(expression.Object)
+
+	case *ast.SpreadExpression:
+		checker.infer(expression.Value)
+
+	case *ast.NamedArgument:
+		checker.infer(expression.Value)
+
+	case *ast.SpawnExpression:
+		checker.infer(expression.Call)
+
+	case *ast.YieldExpression:
+		checker.infer(expression.Value)
+	}
+
+	return Any
+}
+
+func (checker *typeChecker) prefix(expression *ast.PrefixExpression) Type {
+	right := checker.infer(expression.Right)
+
+	switch expression.Operator {
+	case "-":
+		if !checker.compatible(right, Int) {
+			checker.warn(expression.Token, "unknown operator: -%s", right)
+		}
+
+		return Int
+
+	case "!":
+		return Bool
+	}
+
+	return Any
+}
+
+func (checker *typeChecker) infix(expression *ast.InfixExpression) Type {
+	left, right := checker.infer(expression.Left), checker.infer(expression.Right)
+	operator := expression.Operator
+
+	switch operator {
+	case "==", "!=":
+		return Bool
+
+	case "+":
+		if !checker.compatible(left, right) {
+			checker.operatorError(expression, left, right)
+
+			return Any
+		}
+
+		switch t := prune(left).(type) {
+		case *variable:
+			checker.constrain(t)
+
+			return t
+		case Basic:
+			if t != Bool {
+				return t
+			}
+		}
+
+		checker.operatorError(expression, left, right)
+
+		return Any
+
+	case "-", "*", "/", "<", ">":
+		if !checker.try(func() bool { return checker.unify(left, Int) && checker.unify(right, Int) }) {
+			checker.operatorError(expression, left, right)
+		}
+
+		if operator == "<" || operator == ">" {
+			return Bool
+		}
+
+		return Int
+	}
+
+	return Any
+}
+
+// operatorError reports an operation on operands it is not defined for,
+// with the messages the interpreter fails with.
+func (checker *typeChecker) operatorError(expression *ast.InfixExpression, left, right Type) {
+	types := describe(left, right)
+
+	if !checker.unifiable(left, right) {
+		checker.warn(expression.Token, "type mismatch: %s %s %s", types[0], expression.Operator, types[1])
+	} else {
+		checker.warn(expression.Token, "unknown operator: %s %s %s", types[0], expression.Operator, types[1])
+	}
+}
+
+// unifiable reports whether a and b could be unified, without binding
+// anything.
+func (checker *typeChecker) unifiable(a, b Type) bool {
+	result := false
+
+	checker.try(func() bool {
+		result = checker.unify(a, b)
+
+		return false
+	})
+
+	return result
+}
+
+func (checker *typeChecker) function(function *ast.FunctionLiteral) Type {
+	t := &Function{Parameters: []Type{}, Names: []string{}}
+
+	for _, parameter := range function.Parameters {
+		var parameterType Type
+
+		switch {
+		case parameter.Type != nil:
+			parameterType = checker.annotation(parameter.Type)
+		case parameter.Rest:
+			parameterType = &Array{Element: Any}
+		default:
+			parameterType = checker.newVariable()
+		}
+
+		if parameter.Default != nil {
+			checker.expect(parameter.Default, checker.infer(parameter.Default), parameterType, "")
+		}
+
+		if parameter.Rest {
+			// a rest parameter always holds an array, whatever its annotation
+			if array, ok := prune(parameterType).(*Array); ok {
+				t.Rest = array.Element
+			} else {
+				checker.warn(start(parameter.Type), "rest parameter %s must have an array type", parameter.Pattern)
+				parameterType, t.Rest = &Array{Element: Any}, Any
+			}
+
+			checker.pattern(parameter.Pattern, parameterType)
+
+			continue
+		}
+
+		checker.pattern(parameter.Pattern, parameterType)
+
+		name := ""
+
+		if identifier, ok := parameter.Pattern.(*ast.Identifier); ok {
+			name = identifier.Value
+		}
+
+		t.Parameters = append(t.Parameters, parameterType)
+		t.Names = append(t.Names, name)
+
+		if parameter.Default == nil {
+			t.Required++
+		}
+	}
+
+	context := &functionContext{}
+
+	if function.ReturnType != nil {
+		context.expected = checker.annotation(function.ReturnType)
+	}
+
+	checker.functions = append(checker.functions, context)
+	value := checker.block(function.Body)
+	checker.functions = checker.functions[:len(checker.functions)-1]
+
+	switch {
+	case function.IsGenerator:
+		t.Result = Any
+
+	case context.expected != nil:
+		if value != nil && len(function.Body.Statements) > 0 {
+			checker.expect(function.Body.Statements[len(function.Body.Statements)-1], value, context.expected, "")
+		}
+
+		t.Result = context.expected
+
+	default:
+		t.Result = checker.join(context.returns, value)
+
+		if t.Result == nil {
+			t.Result = Any
+		}
+	}
+
+	return t
+}
+
+func (checker *typeChecker) call(call *ast.CallExpression) Type {
+	callee := checker.infer(call.Function)
+	arguments := []Type{}
+	positional := true
+
+	for _, argument := range call.Arguments {
+		switch node := argument.(type) {
+		case *ast.NamedArgument:
+			positional = false
+			argument = node.Value
+		case *ast.SpreadExpression:
+			positional = false
+		}
+
+		arguments = append(arguments, checker.infer(argument))
+	}
+
+	switch function := prune(callee).(type) {
+	case *Function:
+		checker.arguments(call, function, arguments)
+
+		return function.Result
+
+	case *variable:
+		if !positional {
+			return Any
+		}
+
+		result := checker.newVariable()
+		checker.unify(function, &Function{Parameters: arguments, Required: len(arguments), Result: result})
+
+		return result
+
+	case Basic:
+		if function == Any {
+			return Any
+		}
+	}
+
+	checker.warn(start(call.Function), "not a function: %s", callee)
+
+	return Any
+}
+
+// arguments checks the arguments of call, of the given types, against the
+// parameters of function. The arguments after a spread are not known until
+// run time, so only the positional ones before it are checked, along with
+// the named ones.
+func (checker *typeChecker) arguments(call *ast.CallExpression, function *Function, arguments []Type) {
+	count := len(function.Parameters)
+	spread := false
+
+	for _, argument := range call.Arguments {
+		if _, ok := argument.(*ast.SpreadExpression); ok {
+			spread = true
+		}
+	}
+
+	if !spread && (len(arguments) < function.Required || (function.Rest == nil && len(arguments) > count)) {
+		var want string
+
+		switch {
+		case function.Rest != nil:
+			want = fmt.Sprintf("at least %d", function.Required)
+		case function.Required < count:
+			want = fmt.Sprintf("%d to %d", function.Required, count)
+		default:
+			want = fmt.Sprint(count)
+		}
+
+		checker.warn(call.Token, "wrong number of arguments: want %s, got %d", want, len(arguments))
+
+		return
+	}
+
+	// positional arguments after a spread no longer line up with parameters
+	aligned := true
+
+	for i, argument := range arguments {
+		switch node := call.Arguments[i].(type) {
+		case *ast.SpreadExpression:
+			aligned = false
+
+		case *ast.NamedArgument:
+			checker.namedArgument(node, function, argument)
+
+		default:
+			parameter := function.Rest
+
+			if i < count {
+				parameter = function.Parameters[i]
+			}
+
+			if aligned && parameter != nil {
+				checker.expect(node, argument, parameter, fmt.Sprintf("argument %d", i+1))
+			}
+		}
+	}
+}
+
+// namedArgument checks argument, of type t, against the parameter of
+// function it names. Nothing is known about the parameters of a function
+// whose names are unknown.
+func (checker *typeChecker) namedArgument(argument *ast.NamedArgument, function *Function, t Type) {
+	if function.Names == nil {
+		return
+	}
+
+	for i, name := range function.Names {
+		if name == argument.Name.Value {
+			checker.expect(argument.Value, t, function.Parameters[i], "argument "+name)
+
+			return
+		}
+	}
+
+	checker.warn(argument.Name.Token, "unknown parameter %s", argument.Name.Value)
+}
+
+func (checker *typeChecker) hash(hash *ast.HashLiteral) Type {
+	var key, value Type
+
+	for _, pair := range hash.Pairs {
+		pairKey := checker.infer(pair.Key)
+
+		switch prune(pairKey).(type) {
+		case *Array, *Hash, *Function:
+			checker.warn(start(pair.Key), "unusable as hash key: %s", pairKey)
+		}
+
+		key = checker.join(key, pairKey)
+		value = checker.join(value, checker.infer(pair.Value))
+	}
+
+	if key == nil {
+		key, value = checker.newVariable(), checker.newVariable()
+	}
+
+	return &Hash{Key: key, Value: value}
+}
+
+func (checker *typeChecker) index(expression *ast.IndexExpression) Type {
+	left, index := checker.infer(expression.Left), checker.infer(expression.Index)
+
+	switch t := prune(left).(type) {
+	case *Array:
+		checker.expect(expression.Index, index, Int, "")
+
+		return t.Element
+
+	case *Hash:
+		checker.expect(expression.Index, index, t.Key, "")
+
+		return t.Value
+
+	case *Function:
+		checker.warn(start(expression.Left), "index operator not supported: %s", left)
+
+	case Basic:
+		if t == Int || t == Bool {
+			checker.warn(start(expression.Left), "index operator not supported: %s", left)
+		}
+	}
+
+	return Any
+}
@@ -0,0 +1,189 @@
+package checker
+
+import (
+	"monkey/lexer"
+	"monkey/parser"
+	"strings"
+	"testing"
+)
+
+func checkTypes(t *testing.T, input string) *Types {
+	t.Helper()
+
+	newParser := parser.NewParser(lexer.NewLexer(input))
+	program := newParser.ParseProgram()
+
+	if errors := newParser.Errors(); len(errors) > 0 {
+		t.Fatalf("parser errors for %q: %v", input, errors)
+	}
+
+	return CheckTypes(program)
+}
+
+// bindingType returns the type of the first binding of name in source order.
+func bindingType(types *Types, name string) string {
+	found, line, column := "", 0, 0
+
+	for identifier, t := range types.Bindings {
+		position := identifier.Token
+
+		if identifier.Value == name && (found == "" || position.Line < line ||
+			position.Line == line && position.Column < column) {
+			found, line, column = t.String(), position.Line, position.Column
+		}
+	}
+
+	return found
+}
+
+func warnings(types *Types) []string {
+	result := []string{}
+
+	for _, warning := range types.Warnings {
+		result = append(result, warning.String())
+	}
+
+	return result
+}
+
+func TestInferredTypes(t *testing.T) {
+	tests := []struct {
+		input    string
+		name     string
+		expected string
+	}{
+		{"let x = 5;", "x", "int"},
+		{"let y = 5 * 2 - 1;", "y", "int"},
+		{"let s = \"a\" + `b${1}`;", "s", "string"},
+		{"let b = 1 < 2;", "b", "bool"},
+		{"let id = fn(x) { x };", "id", "fn('a) -> 'a"},
+		{"let id = fn(x) { x }; let n = id(1);", "n", "int"},
+		{"let id = fn(x) { x }; let n = id(1); let s = id(\"s\");", "s", "string"},
+		{"let add = fn(a, b) { a + b };", "add", "fn('a, 'a) -> 'a"},
+		{"let add = fn(a, b) { a + b }; let s = add(\"a\", \"b\");", "s", "string"},
+		{"let inc = fn(a) { a + 1 };", "inc", "fn(int) -> int"},
+		{"let apply = fn(f, x) { f(x) };", "apply", "fn(fn('a) -> 'b, 'a) -> 'b"},
+		{"let compose = (f, g) => (x) => g(f(x));", "compose", "fn(fn('a) -> 'b, fn('b) -> 'c) -> fn('a) -> 'c"},
+		{"let fact = fn(n) { if (n < 2) { return 1; }; n * fact(n - 1) };", "fact", "fn(int) -> int"},
+		{"let xs = [1, 2, 3];", "xs", "[int]"},
+		{"let xs = [];", "xs", "['a]"},
+		{"let xs = [1, \"two\"];", "xs", "[any]"},
+		{"let first = [[1]][0];", "first", "[int]"},
+		{"let h = {\"a\": 1, \"b\": 2};", "h", "{string: int}"},
+		{"let v = {\"a\": true}[\"a\"];", "v", "bool"},
+		{"let person = {\"name\": \"Ann\", \"age\": 30};", "person", "{string: any}"},
+		{"let v = if (x) { 1 } else { 2 };", "v", "int"},
+		{"let v = if (x) { 1 } else { \"one\" };", "v", "any"},
+		{"let v = if (x) { 1 };", "v", "any"},
+		{"let f = fn(x) { if (x) { return \"yes\"; }; \"no\" };", "f", "fn('a) -> string"},
+		{"let [a, b] = [1, 2];", "b", "int"},
+		{"let {name} = {\"name\": \"Ann\"};", "name", "string"},
+		{"let n: int = 5;", "n", "int"},
+		{"let f = fn(a: int, b: string = \"\") -> bool { true };", "f", "fn(int, string) -> bool"},
+		{"let f = fn(x, ...rest) { x };", "f", "fn('a, ...any) -> 'a"},
+		{"let f = fn(...xs: [int]) { xs };", "xs", "[int]"},
+		{"let g: fn(int) -> int = fn(x) { x };", "g", "fn(int) -> int"},
+		{"let u = len(\"abc\");", "u", "any"},
+	}
+
+	for _, tt := range tests {
+		types := checkTypes(t, tt.input)
+
+		if got := bindingType(types, tt.name); got != tt.expected {
+			t.Errorf("wrong type of %s in %q. expected=%q, got=%q", tt.name, tt.input, tt.expected, got)
+		}
+
+		if len(types.Warnings) > 0 {
+			t.Errorf("unexpected warnings for %q: %q", tt.input, warnings(types))
+		}
+	}
+}
+
+func TestTypeErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"1 + \"a\";", []string{"1:3: type mismatch: int + string"}},
+		{"true + false;", []string{"1:6: unknown operator: bool + bool"}},
+		{"\"a\" - \"b\";", []string{"1:5: unknown operator: string - string"}},
+		{"-\"a\";", []string{"1:1: unknown operator: -string"}},
+		{"1 < \"2\";", []string{"1:3: type mismatch: int < string"}},
+		{"let x: int = \"five\";", []string{"1:14: expected int, got string"}},
+		{"let xs: [string] = [1];", []string{"1:20: expected [string], got [int]"}},
+		{"let x: number = 5;", []string{"1:8: unknown type number"}},
+		{"let f = fn(a: int) { a }; f(\"s\");", []string{"1:29: argument 1: expected int, got string"}},
+		{"let inc = fn(a) { a + 1 }; inc(true);", []string{"1:32: argument 1: expected int, got bool"}},
+		{"let add = fn(a, b) { a + b }; add(1, \"b\");", []string{"1:38: argument 2: expected int, got string"}},
+		{"let add = fn(a, b) { a + b }; add(true, false);", []string{
+			"1:35: argument 1: expected int or string, got bool",
+			"1:41: argument 2: expected int or string, got bool",
+		}},
+		{"let add = fn(a, b) { a + b }; add([1], [2]);", []string{
+			"1:35: argument 1: expected int or string, got [int]",
+			"1:40: argument 2: expected int or string, got [int]",
+		}},
+		{"let twice = fn(a) { a + a }; let f = fn(b) { twice(b) }; f(true);", []string{"1:60: argument 1: expected int or string, got bool"}},
+		{"let f = fn(a, b = 1) { a }; f(); f(1, 2, 3);", []string{
+			"1:30: wrong number of arguments: want 1 to 2, got 0",
+			"1:35: wrong number of arguments: want 1 to 2, got 3",
+		}},
+		{"let f = fn(a, ...rest) { a }; f();", []string{"1:32: wrong number of arguments: want at least 1, got 0"}},
+		{"let f = fn(x, y: int = 1) { y }; f(1, y: true);", []string{"1:42: argument y: expected int, got bool"}},
+		{"let f = fn(x, y = 1) { y }; f(1, z: 2);", []string{"1:34: unknown parameter z"}},
+		{"let f = fn(x: int, y: int) { y }; f(\"a\", ...xs);", []string{"1:37: argument 1: expected int, got string"}},
+		{"let f = fn(x: int, y: int) { y }; f(...xs, \"a\");", []string{}},
+		{"let n = 5; n(1);", []string{"1:12: not a function: int"}},
+		{"let f = fn() -> int { \"a\" };", []string{"1:23: expected int, got string"}},
+		{"let f = fn(x) -> int { if (x) { return true; }; 1 };", []string{"1:40: expected int, got bool"}},
+		{"let f = fn(x = \"a\") -> int { x };", []string{"1:30: expected int, got string"}},
+		{"let f = fn(a: int = \"a\") { a };", []string{"1:21: expected int, got string"}},
+		{"let f = fn(...xs: int) { xs };", []string{"1:19: rest parameter xs must have an array type"}},
+		{"let f = fn(...xs: int) { xs[0] + len(xs) };", []string{"1:19: rest parameter xs must have an array type"}},
+		{"[1, 2][\"a\"];", []string{"1:8: expected int, got string"}},
+		{"{\"a\": 1}[1];", []string{"1:10: expected string, got int"}},
+		{"5[0];", []string{"1:1: index operator not supported: int"}},
+		{"{[1]: 2};", []string{"1:2: unusable as hash key: [int]"}},
+		{"let f = fn(g) { g(1) + 1 }; f(fn(s) { s + \"!\" });", []string{"1:31: argument 1: expected fn(int) -> int, got fn(string) -> string"}},
+		{"let fact = fn(n) { if (n < 2) { return 1; }; n * fact(n - 1) }; fact(\"ten\");", []string{
+			"1:70: argument 1: expected int, got string",
+		}},
+	}
+
+	for _, tt := range tests {
+		got := warnings(checkTypes(t, tt.input))
+
+		if strings.Join(got, "\n") != strings.Join(tt.expected, "\n") {
+			t.Errorf("wrong warnings for %q.\nexpected=%q\ngot=     %q", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestDynamicCodeIsAccepted(t *testing.T) {
+	tests := []string{
+		`let v = if (flag) { 1 } else { "one" }; puts(v);`,
+		`let items = [1, "two", [3], {"four": 4}]; len(items);`,
+		`let person = {"name": "Ann", "age": 30}; person["age"]; person["name"];`,
+		`let id = fn(x) { x }; id(1); id("a"); id([true]);`,
+		`let f = fn(g) { g(1) }; f(fn(a, b = 2) { a + b });`,
+		`let f = fn(...xs) { len(xs) }; f(1, "a", true);`,
+		`let f = fn(x) { x(x) };`,
+		`let a = fn() { b() + 1 }; let b = fn() { "b" };`,
+		`struct Point { x, y } let p = Point(1, 2); p.x + 1;`,
+		`enum Shape { Circle(r), Square } match s { Circle(r) => r * 2, Square => "square" };`,
+		`match v { [a, b] => a, {name} => name, 1 => "one", _ => 0 };`,
+		`import "math" as m; m.max(1, 2) + 1;`,
+		"let s = `n = ${n}`; s + \"!\";",
+		`let f = fn(x) { if (x) { return 1; }; "none" }; f(true) + 1;`,
+		`try { risky() } catch (err) { err + 1 }`,
+		`let next = fn*() { yield 1; yield "two"; };`,
+		`[1, 2] |> map((x) => x * 2) |> sum;`,
+		`let xs = [1, 2, 3]; xs[1:] ; "abc"[1:2] + "d";`,
+	}
+
+	for _, input := range tests {
+		if got := warnings(checkTypes(t, input)); len(got) > 0 {
+			t.Errorf("unexpected warnings for %q: %q", input, got)
+		}
+	}
+}
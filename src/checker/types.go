@@ -0,0 +1,142 @@
+package checker
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Type is the static type of a value: a Basic type, an Array, a Hash or a
+// Function, or a type variable standing for a type that is not known yet.
+type Type interface {
+	String() string
+	isType()
+}
+
+// Basic is a type without parts.
+type Basic string
+
+const (
+	Int    Basic = "int"
+	Bool   Basic = "bool"
+	String Basic = "string"
+
+	// Any is the type of values that are only known at run time, such as
+	// the elements of an array mixing integers and strings. It is
+	// compatible with every type.
+	Any Basic = "any"
+)
+
+var basicTypes = map[string]Basic{"int": Int, "bool": Bool, "string": String, "any": Any}
+
+type Array struct {
+	Element Type
+}
+
+type Hash struct {
+	Key   Type
+	Value Type
+}
+
+// Function is the type of a function. Parameters with a default value
+// follow the Required ones, and Rest is the element type of a trailing
+// ...rest parameter, or nil when there is none. Names holds the name of
+// each of the Parameters, empty for a destructuring pattern; it is nil when
+// the names are not known, as for a function type annotation.
+type Function struct {
+	Parameters []Type
+	Names      []string
+	Required   int
+	Rest       Type
+	Result     Type
+}
+
+// generic is the level of the type variables of a generalized type, which
+// are replaced by fresh variables wherever the type is used.
+const generic = math.MaxInt
+
+type variable struct {
+	level    int  // the let nesting depth the variable was created at
+	instance Type // the type the variable stands for, once known
+	operand  bool // whether it is added with +, so that it stands for an int or a string
+}
+
+func (Basic) isType()              {}
+func (*Array) isType()             {}
+func (*Hash) isType()              {}
+func (*Function) isType()          {}
+func (*variable) isType()          {}
+func (t Basic) String() string     { return string(t) }
+func (t *Array) String() string    { return describe(t)[0] }
+func (t *Hash) String() string     { return describe(t)[0] }
+func (t *Function) String() string { return describe(t)[0] }
+func (t *variable) String() string { return describe(t)[0] }
+
+// prune returns the type t stands for, following bound type variables.
+func prune(t Type) Type {
+	if v, ok := t.(*variable); ok && v.instance != nil {
+		return prune(v.instance)
+	}
+
+	return t
+}
+
+// describe formats types for one message, naming type variables 'a, 'b and
+// so on in order of appearance across all of them.
+func describe(types ...Type) []string {
+	printer := &printer{names: map[*variable]string{}}
+	descriptions := []string{}
+
+	for _, t := range types {
+		descriptions = append(descriptions, printer.print(t))
+	}
+
+	return descriptions
+}
+
+type printer struct {
+	names map[*variable]string
+}
+
+func (printer *printer) print(t Type) string {
+	switch t := prune(t).(type) {
+	case *Array:
+		return "[" + printer.print(t.Element) + "]"
+
+	case *Hash:
+		return "{" + printer.print(t.Key) + ": " + printer.print(t.Value) + "}"
+
+	case *Function:
+		parameters := []string{}
+
+		for _, parameter := range t.Parameters {
+			parameters = append(parameters, printer.print(parameter))
+		}
+
+		if t.Rest != nil {
+			parameters = append(parameters, "..."+printer.print(t.Rest))
+		}
+
+		return "fn(" + strings.Join(parameters, ", ") + ") -> " + printer.print(t.Result)
+
+	case *variable:
+		if _, ok := printer.names[t]; !ok {
+			printer.names[t] = variableName(len(printer.names))
+		}
+
+		return printer.names[t]
+
+	default:
+		return t.String()
+	}
+}
+
+func variableName(index int) string {
+	name := "'" + string(rune('a'+index%26))
+
+	if index >= 26 {
+		name += fmt.Sprint(index / 26)
+	}
+
+	return name
+}
@@ -0,0 +1,430 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"monkey/ast"
+	"monkey/astdump"
+	"monkey/checker"
+	"monkey/formatter"
+	"monkey/lexer"
+	"monkey/lint"
+	"monkey/optimizer"
+	"monkey/parser"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// commands maps subcommand names to functions that take the remaining
+// arguments and return the exit status.
+var commands = map[string]func(args []string) int{
+	"ast":   astCommand,
+	"check": checkCommand,
+	"fmt":   fmtCommand,
+	"lint":  lintCommand,
+}
+
+// astCommand prints the syntax tree of a file or of the -e expression.
+func astCommand(args []string) int {
+	flags := flag.NewFlagSet("ast", flag.ContinueOnError)
+	format := flags.String("format", "sexpr", "output format: sexpr or dot")
+	positions := flags.Bool("positions", false, "label nodes with line:column")
+	expression := flags.String("e", "", "parse `source` instead of a file")
+	optimize := flags.Bool("optimize", false, "fold constants, inline const bindings and drop dead branches first")
+
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: monkey ast [flags] [file]\n")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	name, source, err := readSource(flags, *expression)
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	program, ok := parseSource(name, source)
+
+	if !ok {
+		return 1
+	}
+
+	if *optimize {
+		program = optimizer.Optimize(program)
+	}
+
+	options := astdump.Options{Positions: *positions}
+
+	switch *format {
+	case "sexpr":
+		fmt.Print(astdump.SExpr(program, options))
+	case "dot":
+		fmt.Print(astdump.DOT(program, options))
+	default:
+		fmt.Fprintf(os.Stderr, "unknown format %q, expected sexpr or dot\n", *format)
+		return 2
+	}
+
+	return 0
+}
+
+// fmtCommand formats the given files, or standard input if there are none.
+// With -check it only reports whether every file is already formatted,
+// which is what a pre-commit hook wants.
+func fmtCommand(args []string) int {
+	flags := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	write := flags.Bool("w", false, "write the result back to the files instead of printing it")
+	list := flags.Bool("l", false, "list files whose formatting differs")
+	check := flags.Bool("check", false, "list unformatted files and exit with status 1 if there are any")
+	width := flags.Int("width", formatter.DefaultWidth, "preferred maximum line `width`")
+
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: monkey fmt [flags] [files]\n")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	options := formatter.Options{Width: *width}
+
+	if flags.NArg() == 0 {
+		if *write {
+			fmt.Fprintln(os.Stderr, "fmt: cannot use -w with standard input")
+			return 2
+		}
+
+		source, err := io.ReadAll(os.Stdin)
+
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+
+		return formatFile("<stdin>", source, options, *list, *check, false)
+	}
+
+	status := 0
+
+	for _, name := range flags.Args() {
+		source, err := os.ReadFile(name)
+
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			status = 2
+			continue
+		}
+
+		if result := formatFile(name, source, options, *list, *check, *write); result > status {
+			status = result
+		}
+	}
+
+	return status
+}
+
+// formatFile formats one file's source and prints, lists or writes the
+// result. It returns 1 if the file does not parse, or if check is set and
+// the file is not formatted.
+func formatFile(name string, source []byte, options formatter.Options, list, check, write bool) int {
+	formatted, err := formatter.Source(source, options)
+
+	if err != nil {
+		for _, message := range strings.Split(err.Error(), "\n") {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", name, message)
+		}
+
+		return 1
+	}
+
+	changed := !bytes.Equal(source, formatted)
+
+	if (list || check) && changed {
+		fmt.Println(name)
+	}
+
+	if check {
+		if changed {
+			return 1
+		}
+
+		return 0
+	}
+
+	if write {
+		if !changed {
+			return 0
+		}
+
+		if err := os.WriteFile(name, formatted, 0644); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+
+		return 0
+	}
+
+	if !list {
+		os.Stdout.Write(formatted)
+	}
+
+	return 0
+}
+
+// lintCommand runs the linter over the given files, or standard input if
+// there are none. Unless -config names one, every file uses the
+// lint.ConfigFile found in its directory or above.
+func lintCommand(args []string) int {
+	flags := flag.NewFlagSet("lint", flag.ContinueOnError)
+	format := flags.String("format", "text", "output format: text or json")
+	configPath := flags.String("config", "", "read the configuration from `file`")
+	listRules := flags.Bool("rules", false, "list the available rules and exit")
+
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: monkey lint [flags] [files]\n")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if *listRules {
+		for _, rule := range lint.Rules {
+			fmt.Printf("%-20s %s\n", rule.Name, rule.Doc)
+		}
+
+		return 0
+	}
+
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "unknown format %q, expected text or json\n", *format)
+		return 2
+	}
+
+	type report struct {
+		File string `json:"file"`
+		lint.Finding
+	}
+
+	reports := []report{}
+	status := 0
+
+	lintFile := func(name string, source []byte, dir string) {
+		path := *configPath
+		var err error
+
+		if path == "" {
+			path, err = lint.FindConfig(dir)
+		}
+
+		config := lint.Config{}
+
+		if err == nil && path != "" {
+			config, err = lint.LoadConfig(path)
+		}
+
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			status = 2
+			return
+		}
+
+		findings, err := lint.Source(source, config)
+
+		if err != nil {
+			for _, message := range strings.Split(err.Error(), "\n") {
+				fmt.Fprintf(os.Stderr, "%s: %s\n", name, message)
+			}
+
+			status = max(status, 1)
+			return
+		}
+
+		for _, finding := range findings {
+			reports = append(reports, report{File: name, Finding: finding})
+		}
+	}
+
+	if flags.NArg() == 0 {
+		source, err := io.ReadAll(os.Stdin)
+
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+
+		lintFile("<stdin>", source, ".")
+	}
+
+	for _, name := range flags.Args() {
+		source, err := os.ReadFile(name)
+
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			status = 2
+			continue
+		}
+
+		lintFile(name, source, filepath.Dir(name))
+	}
+
+	if *format == "json" {
+		encoded, _ := json.MarshalIndent(reports, "", "  ")
+		fmt.Println(string(encoded))
+	} else {
+		for _, report := range reports {
+			fmt.Printf("%s:%s\n", report.File, report.Finding)
+		}
+	}
+
+	if len(reports) > 0 {
+		status = max(status, 1)
+	}
+
+	return status
+}
+
+// checkCommand infers the types in the given files, or standard input if
+// there are none, and reports type errors and non-exhaustive matches.
+func checkCommand(args []string) int {
+	flags := flag.NewFlagSet("check", flag.ContinueOnError)
+	showTypes := flags.Bool("types", false, "print the inferred types of top-level bindings")
+
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: monkey check [flags] [files]\n")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	status := 0
+
+	checkFile := func(name string, source []byte) {
+		program, ok := parseSource(name, string(source))
+
+		if !ok {
+			status = max(status, 1)
+			return
+		}
+
+		types := checker.CheckTypes(program)
+
+		if *showTypes {
+			for _, binding := range topLevelNames(program) {
+				fmt.Printf("%s:%d:%d: %s: %s\n", name, binding.Token.Line, binding.Token.Column,
+					binding.Value, types.Bindings[binding])
+			}
+		}
+
+		warnings := append(checker.CheckMatches(program), types.Warnings...)
+
+		sort.SliceStable(warnings, func(i, j int) bool {
+			if warnings[i].Line != warnings[j].Line {
+				return warnings[i].Line < warnings[j].Line
+			}
+
+			return warnings[i].Column < warnings[j].Column
+		})
+
+		for _, warning := range warnings {
+			fmt.Printf("%s:%s\n", name, warning)
+		}
+
+		if len(warnings) > 0 {
+			status = max(status, 1)
+		}
+	}
+
+	if flags.NArg() == 0 {
+		source, err := io.ReadAll(os.Stdin)
+
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+
+		checkFile("<stdin>", source)
+	}
+
+	for _, name := range flags.Args() {
+		source, err := os.ReadFile(name)
+
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			status = 2
+			continue
+		}
+
+		checkFile(name, source)
+	}
+
+	return status
+}
+
+// topLevelNames returns the names bound by the let statements of program,
+// exported or not, in source order.
+func topLevelNames(program *ast.Program) []*ast.Identifier {
+	names := []*ast.Identifier{}
+
+	for _, statement := range program.Statemens {
+		if export, ok := statement.(*ast.ExportStatement); ok {
+			statement = export.Declaration
+		}
+
+		if let, ok := statement.(*ast.LetStatement); ok {
+			names = append(names, ast.PatternNames(let.Target())...)
+		}
+	}
+
+	return names
+}
+
+// readSource returns the expression given with -e, or else the contents of
+// the single file argument, together with a name for error messages.
+func readSource(flags *flag.FlagSet, expression string) (string, string, error) {
+	if expression != "" {
+		if flags.NArg() > 0 {
+			return "", "", fmt.Errorf("%s: cannot use -e together with a file", flags.Name())
+		}
+
+		return "<expression>", expression, nil
+	}
+
+	if flags.NArg() != 1 {
+		return "", "", fmt.Errorf("%s: expected one file or -e", flags.Name())
+	}
+
+	source, err := os.ReadFile(flags.Arg(0))
+
+	if err != nil {
+		return "", "", err
+	}
+
+	return flags.Arg(0), string(source), nil
+}
+
+// parseSource parses source and reports any parser errors on stderr.
+func parseSource(name, source string) (*ast.Program, bool) {
+	newParser := parser.NewParser(lexer.NewLexer(source))
+	program := newParser.ParseProgram()
+
+	for _, message := range newParser.Errors() {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", name, message)
+	}
+
+	return program, len(newParser.Errors()) == 0
+}
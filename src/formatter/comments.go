@@ -0,0 +1,316 @@
+package formatter
+
+import (
+	"monkey/ast"
+	"monkey/token"
+	"reflect"
+)
+
+// A span is the source range of a node, from its first token to its last,
+// including the closing brackets the AST does not record.
+type span struct {
+	node       ast.Node
+	parent     int // index of the enclosing span, or -1
+	start, end position
+}
+
+// spanner collects the spans of a statement in depth-first order. Braced
+// blocks are spanned but not entered, as their statements attach their own
+// comments.
+type spanner struct {
+	formatter *formatter
+	spans     []span
+	open      []int // indexes of the spans being visited
+}
+
+func (spanner *spanner) Visit(node ast.Node) ast.Visitor {
+	if node == nil {
+		spanner.close()
+
+		return nil
+	}
+
+	parent := -1
+
+	if len(spanner.open) > 0 {
+		parent = spanner.open[len(spanner.open)-1]
+	}
+
+	current := span{node: node, parent: parent}
+
+	if field := reflect.ValueOf(node).Elem().FieldByName("Token"); field.IsValid() {
+		if tok := field.Interface().(token.Token); tok.Line > 0 {
+			current.start, current.end = positionOf(tok), positionOf(tok)
+		}
+	}
+
+	spanner.spans = append(spanner.spans, current)
+	spanner.open = append(spanner.open, len(spanner.spans)-1)
+
+	if block, ok := node.(*ast.BlockStatement); ok && block.Token.Type == token.LBRACE {
+		spanner.close()
+
+		return nil
+	}
+
+	return spanner
+}
+
+// close completes the innermost open span and widens its parent to cover it.
+func (spanner *spanner) close() {
+	i := spanner.open[len(spanner.open)-1]
+	spanner.open = spanner.open[:len(spanner.open)-1]
+	current := &spanner.spans[i]
+
+	if current.start.line == 0 {
+		return
+	}
+
+	current.end = spanner.formatter.closing(current.start, current.end)
+
+	if current.parent >= 0 {
+		parent := &spanner.spans[current.parent]
+
+		if parent.start.line == 0 || current.start.before(parent.start) {
+			parent.start = current.start
+		}
+
+		if parent.end.before(current.end) {
+			parent.end = current.end
+		}
+	}
+}
+
+// closing returns the end of a node whose tokens lie between start and last:
+// the closer of the outermost bracket opened in that range and still open
+// at last, or last itself.
+func (formatter *formatter) closing(start, last position) position {
+	i := formatter.tokensBefore(last)
+
+	if i == len(formatter.tokens) || positionOf(formatter.tokens[i]) != last {
+		return last
+	}
+
+	end := last
+
+	if closer, ok := formatter.closers[last]; ok {
+		end = closer
+	}
+
+	for j := formatter.enclosing[i]; j >= 0 && !positionOf(formatter.tokens[j]).before(start); j = formatter.enclosing[j] {
+		end = formatter.closers[positionOf(formatter.tokens[j])]
+	}
+
+	return end
+}
+
+// attach assigns the comments between the first and the last token of
+// statement to the nearest node enclosing each of them. A comment is
+// printed with the child of that node it trails on the same line or, when
+// the node goes on after it, with the child it precedes. Comments between
+// list items and between the statements of a block are left to those;
+// those after the last child of the statement trail the statement.
+func (formatter *formatter) attach(statement ast.Statement, first, last position) {
+	inside := []int{}
+
+	for i, comment := range formatter.comments {
+		if at := positionOf(comment); !formatter.printed[i] && first.before(at) && at.before(last) {
+			inside = append(inside, i)
+		}
+	}
+
+	if len(inside) == 0 {
+		return
+	}
+
+	spanner := &spanner{formatter: formatter}
+	ast.Walk(spanner, statement)
+	spans := spanner.spans
+	spans[0].start, spans[0].end = first, last
+
+	for _, i := range inside {
+		at := positionOf(formatter.comments[i])
+		nearest := 0
+
+		// a span that contains the comment is nested in all those before it
+		for j := range spans {
+			if spans[j].start.before(at) && at.before(spans[j].end) {
+				nearest = j
+			}
+		}
+
+		node := spans[nearest].node
+
+		if block, ok := node.(*ast.BlockStatement); ok && block.Token.Type == token.LBRACE {
+			continue
+		}
+
+		opener, list := formatter.listOpener(node)
+
+		if list && opener.before(at) && at.before(formatter.closers[opener]) {
+			continue
+		}
+
+		// only children inside the node's innermost brackets around the
+		// comment, such as the ${} of a template, can take it
+		lower, upper := spans[nearest].start, spans[nearest].end
+
+		if opener, ok := formatter.openerAround(at); ok && !opener.before(lower) {
+			lower, upper = opener, formatter.closers[opener]
+		}
+
+		previous, next := -1, -1
+
+		for j := nearest + 1; j < len(spans); j++ {
+			if spans[j].parent != nearest || spans[j].start.line == 0 || spans[j].start.before(lower) || upper.before(spans[j].end) {
+				continue
+			}
+
+			// template text cannot hold a comment
+			if _, ok := spans[j].node.(*ast.TemplateString); ok {
+				continue
+			}
+
+			if spans[j].end.before(at) && (previous < 0 || spans[previous].end.before(spans[j].end)) {
+				previous = j
+			} else if at.before(spans[j].start) && (next < 0 || spans[j].start.before(spans[next].start)) {
+				next = j
+			}
+		}
+
+		switch {
+		case list && next >= 0 && at.before(opener) && opener.before(spans[next].start):
+			// a comment before the bracket of a list goes first in it
+			formatter.opening[node] = append(formatter.opening[node], i)
+		case nearest == 0 && next < 0:
+			// only the semicolon follows
+			formatter.trailing[statement] = append(formatter.trailing[statement], i)
+		case previous >= 0 && (next < 0 || formatter.trails(formatter.comments[i], spans[previous], node)):
+			formatter.trailing[spans[previous].node] = append(formatter.trailing[spans[previous].node], i)
+		case next >= 0:
+			formatter.leading[spans[next].node] = append(formatter.leading[spans[next].node], i)
+		default:
+			formatter.trailing[statement] = append(formatter.trailing[statement], i)
+		}
+	}
+}
+
+// openerAround returns the position of the innermost bracket, brace or
+// ${ that is open at p.
+func (formatter *formatter) openerAround(p position) (position, bool) {
+	i := formatter.tokensBefore(p) - 1
+
+	if i < 0 {
+		return position{}, false
+	}
+
+	if _, ok := formatter.closers[positionOf(formatter.tokens[i])]; !ok {
+		i = formatter.enclosing[i]
+	}
+
+	if i < 0 {
+		return position{}, false
+	}
+
+	return positionOf(formatter.tokens[i]), true
+}
+
+// trails reports whether comment, which follows the child previous of
+// parent, stays behind it rather than moving to the next child: when it is
+// on the line previous ends, behind a closing brace, a comma or a pipeline
+// stage, all of which the layout may follow with a line break.
+func (formatter *formatter) trails(comment token.Token, previous span, parent ast.Node) bool {
+	before := formatter.lastTokenBefore(positionOf(comment))
+
+	if comment.Line != formatter.endLine(before) {
+		return false
+	}
+
+	comma := before.Type == token.COMMA
+
+	if comma {
+		before = formatter.lastTokenBefore(positionOf(before))
+	}
+
+	if positionOf(before) != previous.end {
+		return false
+	}
+
+	if call, ok := parent.(*ast.CallExpression); ok && call.Piped && len(call.Arguments) > 0 && call.Arguments[0] == previous.node {
+		return true
+	}
+
+	return comma || before.Type == token.RBRACE
+}
+
+// take returns the comments attached to node in attachments and marks them
+// printed.
+func (formatter *formatter) take(attachments map[ast.Node][]int, node ast.Node) []token.Token {
+	comments := []token.Token{}
+
+	for _, i := range attachments[node] {
+		if !formatter.printed[i] {
+			comments = append(comments, formatter.comments[i])
+			formatter.printed[i] = true
+		}
+	}
+
+	delete(attachments, node)
+
+	return comments
+}
+
+// ownLine reports whether no token precedes comment on its line.
+func (formatter *formatter) ownLine(comment token.Token) bool {
+	before := formatter.lastTokenBefore(positionOf(comment))
+
+	return before.Line == 0 || formatter.endLine(before) < comment.Line
+}
+
+// attached lays out d, the doc of node, with the comments attached to it.
+// Those before it go on lines of their own unless the first one ends the
+// line of code before it; those after it end the line.
+func (formatter *formatter) attached(node ast.Node, d doc) doc {
+	if comments := formatter.take(formatter.leading, node); len(comments) > 0 {
+		before := leading{ownLine: formatter.ownLine(comments[0]), doc: d}
+
+		for _, comment := range comments {
+			before.comments = append(before.comments, comment.Literal)
+		}
+
+		d = before
+	}
+
+	for i, trailing := range formatter.take(formatter.trailing, node) {
+		if i == 0 && !formatter.ownLine(trailing) {
+			d = concat{d, comment{trailing.Literal, true}}
+		} else {
+			d = concat{d, indent{hardline, comment{trailing.Literal, true}}}
+		}
+	}
+
+	return d
+}
+
+// attachedLines is attached for the items of a list laid out one per line,
+// such as match arms: the comments around an item that do not end its line
+// go on lines of their own, indented like the item.
+func (formatter *formatter) attachedLines(node ast.Node, d doc) doc {
+	out := concat{}
+
+	for _, comment := range formatter.take(formatter.leading, node) {
+		out = append(out, text(comment.Literal), hardline)
+	}
+
+	out = append(out, d)
+
+	for i, trailing := range formatter.take(formatter.trailing, node) {
+		if i == 0 && !formatter.ownLine(trailing) {
+			out = append(out, comment{trailing.Literal, true})
+		} else {
+			out = append(out, hardline, comment{trailing.Literal, true})
+		}
+	}
+
+	return out
+}
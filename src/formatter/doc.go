@@ -0,0 +1,266 @@
+package formatter
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// A doc describes layout independently of the line width, in the style of
+// Wadler's "prettier printer". The printer decides for every group whether
+// its lines become spaces or line breaks.
+type doc interface{}
+
+type (
+	text string // printed as is
+
+	// comment is a // comment ending a line, set off by a space from any
+	// text before it. The line breaks after it: at the next line, whatever
+	// its group, or before the next text. A group holding a comment that
+	// trails the code before it does not fit on one line.
+	comment struct {
+		text     string
+		trailing bool
+	}
+
+	// leading is doc preceded by comments, each on a line of its own but
+	// the first, which ends the line of code before it unless ownLine is
+	// set. Comments that start a line keep its indentation for doc; after
+	// a line of code, doc continues indented on the next line.
+	leading struct {
+		comments []string
+		ownLine  bool
+		doc      doc
+	}
+
+	// line is a space, or nothing if soft, when its group fits on the
+	// current line and a line break otherwise. A hard line always breaks.
+	line struct{ soft, hard bool }
+
+	concat []doc
+
+	// group is printed flat if everything up to its first hard line fits
+	// into the remaining width, and broken otherwise.
+	group []doc
+
+	// indent indents every line break inside it by one level.
+	indent []doc
+
+	// breakIndent indents like indent, but only when the innermost group
+	// around it is broken. Hard line breaks inside a flat group, such as
+	// the body of a function passed as an argument, keep the indentation
+	// of the line they started on.
+	breakIndent []doc
+)
+
+var (
+	space     = line{}
+	softline  = line{soft: true}
+	hardline  = line{hard: true}
+	blankline = concat{hardline, hardline}
+)
+
+type mode int
+
+const (
+	flat mode = iota
+	broken
+)
+
+type command struct {
+	indent int
+	mode   mode
+	doc    doc
+}
+
+// render lays out d so that lines fit into width columns where possible.
+func render(d doc, width int, indentation string) string {
+	out := []byte{}
+	column := 0
+	commands := []command{{indent: 0, mode: broken, doc: d}}
+	afterComment := false // whether the line must break before anything else
+	lineStart := true     // whether nothing but indentation is on the line
+
+	newline := func(indent int) {
+		for len(out) > 0 && out[len(out)-1] == ' ' {
+			out = out[:len(out)-1]
+		}
+
+		out = append(out, '\n')
+		out = append(out, strings.Repeat(indentation, indent)...)
+		column = indent * utf8.RuneCountInString(indentation)
+		afterComment = false
+		lineStart = true
+	}
+
+	for len(commands) > 0 {
+		current := commands[len(commands)-1]
+		commands = commands[:len(commands)-1]
+
+		switch d := current.doc.(type) {
+		case nil:
+			// nothing to print
+
+		case text:
+			if afterComment && d != "" {
+				newline(current.indent)
+				d = text(strings.TrimLeft(string(d), " "))
+			}
+
+			out = append(out, d...)
+			column += utf8.RuneCountInString(string(d))
+			lineStart = lineStart && d == ""
+
+		case comment:
+			if len(out) > 0 && out[len(out)-1] != ' ' && out[len(out)-1] != '\n' {
+				out = append(out, ' ')
+				column++
+			}
+
+			out = append(out, d.text...)
+			column += utf8.RuneCountInString(d.text)
+			afterComment = true
+			lineStart = false
+
+		case leading:
+			level := current.indent
+
+			if !lineStart {
+				level++
+
+				if d.ownLine {
+					newline(level)
+				}
+			}
+
+			docs := []doc{}
+
+			for _, text := range d.comments {
+				docs = append(docs, comment{text: text}, hardline)
+			}
+
+			commands = pushReversed(commands, level, current.mode, append(docs, d.doc))
+
+		case line:
+			if current.mode == flat && !d.hard && !afterComment {
+				if !d.soft {
+					out = append(out, ' ')
+					column++
+				}
+
+				continue
+			}
+
+			newline(current.indent)
+
+		case concat:
+			commands = pushReversed(commands, current.indent, current.mode, d)
+
+		case group:
+			next := command{indent: current.indent, mode: flat, doc: concat(d)}
+
+			if !fits(next, commands, width-column) {
+				next.mode = broken
+			}
+
+			commands = append(commands, next)
+
+		case indent:
+			commands = pushReversed(commands, current.indent+1, current.mode, d)
+
+		case breakIndent:
+			level := current.indent
+
+			if current.mode == broken {
+				level++
+			}
+
+			commands = pushReversed(commands, level, current.mode, d)
+		}
+	}
+
+	return string(out)
+}
+
+func pushReversed(commands []command, indent int, mode mode, docs []doc) []command {
+	for i := len(docs) - 1; i >= 0; i-- {
+		commands = append(commands, command{indent: indent, mode: mode, doc: docs[i]})
+	}
+
+	return commands
+}
+
+// fits reports whether next, followed by the remaining commands, can be
+// printed up to the next line break without exceeding width. A trailing
+// comment in next does not fit, as the line break after it must not end
+// up in the middle of a flat group.
+func fits(next command, rest []command, width int) bool {
+	commands := []command{next}
+	inRest := false
+
+	for width >= 0 {
+		if len(commands) == 0 {
+			if len(rest) == 0 {
+				return true
+			}
+
+			commands = append(commands, rest[len(rest)-1])
+			rest = rest[:len(rest)-1]
+			inRest = true
+
+			continue
+		}
+
+		current := commands[len(commands)-1]
+		commands = commands[:len(commands)-1]
+
+		switch d := current.doc.(type) {
+		case text:
+			width -= utf8.RuneCountInString(string(d))
+
+		case comment:
+			return (inRest || !d.trailing) && width > utf8.RuneCountInString(d.text)
+
+		case leading:
+			// the line breaks after the first comment, however long
+			return true
+
+		case line:
+			if current.mode == broken || d.hard {
+				return true
+			}
+
+			if !d.soft {
+				width--
+			}
+
+		case concat:
+			commands = pushReversed(commands, current.indent, current.mode, d)
+
+		case group:
+			commands = pushReversed(commands, current.indent, flat, d)
+
+		case indent:
+			commands = pushReversed(commands, current.indent, current.mode, d)
+
+		case breakIndent:
+			commands = pushReversed(commands, current.indent, current.mode, d)
+		}
+	}
+
+	return false
+}
+
+// join puts separator between docs.
+func join(docs []doc, separator ...doc) concat {
+	joined := concat{}
+
+	for i, d := range docs {
+		if i > 0 {
+			joined = append(joined, separator...)
+		}
+
+		joined = append(joined, d)
+	}
+
+	return joined
+}
@@ -0,0 +1,1091 @@
+// Package formatter lays out Monkey source code canonically.
+//
+// Statements end in semicolons and blocks are indented by four spaces.
+// Argument lists, literals and operator chains are kept on one line when
+// they fit into the width limit and broken over several lines otherwise.
+// Comments stay with the statement, list item or node they precede or
+// trail, and blank lines between statements are kept, collapsed to one.
+// Formatting formatted source leaves it unchanged.
+package formatter
+
+import (
+	"fmt"
+	"monkey/ast"
+	"monkey/lexer"
+	"monkey/parser"
+	"monkey/token"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DefaultWidth is the line width used when Options.Width is not set.
+const DefaultWidth = 80
+
+const indentation = "    "
+
+type Options struct {
+	Width int // preferred maximum line width, DefaultWidth if zero
+}
+
+// Source formats a Monkey program. It returns an error listing the parser
+// errors if source does not parse.
+func Source(source []byte, options Options) ([]byte, error) {
+	newParser := parser.NewParser(lexer.NewLexer(string(source)))
+	program := newParser.ParseProgram()
+
+	if errors := newParser.Errors(); len(errors) > 0 {
+		return nil, fmt.Errorf("%s", strings.Join(errors, "\n"))
+	}
+
+	width := options.Width
+
+	if width <= 0 {
+		width = DefaultWidth
+	}
+
+	formatter := newFormatter(string(source))
+	statements := formatter.statements(program.Statemens, position{}, formatter.end)
+
+	if len(statements) == 0 {
+		return []byte{}, nil
+	}
+
+	return []byte(render(concat{statements, hardline}, width, indentation)), nil
+}
+
+type formatter struct {
+	source      string
+	lineOffsets []int // byte offset of the start of every line
+
+	tokens   []token.Token // every token of the source except comments and EOF
+	comments []token.Token
+	printed  []bool // per comment
+
+	closers   map[position]position // position of every bracket and backtick to its matching closer
+	enclosing []int                 // per token, the index of the innermost opener around it, or -1
+	end       position              // position of EOF
+
+	// comments attached to nodes, by index into comments
+	leading  map[ast.Node][]int
+	trailing map[ast.Node][]int
+	opening  map[ast.Node][]int // before the first item of a list
+}
+
+type position struct {
+	line, column int
+}
+
+func positionOf(tok token.Token) position {
+	return position{tok.Line, tok.Column}
+}
+
+func (p position) before(other position) bool {
+	return p.line < other.line || p.line == other.line && p.column < other.column
+}
+
+func newFormatter(source string) *formatter {
+	formatter := &formatter{
+		source:      source,
+		lineOffsets: []int{0},
+		closers:     map[position]position{},
+		leading:     map[ast.Node][]int{},
+		trailing:    map[ast.Node][]int{},
+		opening:     map[ast.Node][]int{},
+	}
+
+	for i := 0; i < len(source); i++ {
+		if source[i] == '\n' {
+			formatter.lineOffsets = append(formatter.lineOffsets, i+1)
+		}
+	}
+
+	newLexer := lexer.NewLexer(source)
+	open := []int{}
+
+	for tok := newLexer.NextToken(); tok.Type != token.EOF; tok = newLexer.NextToken() {
+		switch tok.Type {
+		case token.RPAREN, token.RBRACKET, token.RBRACE, token.TEMPLATE_EXPR_END, token.TEMPLATE_END:
+			if len(open) > 0 {
+				formatter.closers[positionOf(formatter.tokens[open[len(open)-1]])] = positionOf(tok)
+				open = open[:len(open)-1]
+			}
+		}
+
+		if len(open) > 0 {
+			formatter.enclosing = append(formatter.enclosing, open[len(open)-1])
+		} else {
+			formatter.enclosing = append(formatter.enclosing, -1)
+		}
+
+		switch tok.Type {
+		case token.LPAREN, token.LBRACKET, token.LBRACE, token.TEMPLATE_EXPR_START, token.TEMPLATE_START:
+			open = append(open, len(formatter.tokens))
+		}
+
+		formatter.tokens = append(formatter.tokens, tok)
+
+		formatter.end = position{tok.Line, tok.Column + 1}
+	}
+
+	formatter.comments = newLexer.Comments()
+	formatter.printed = make([]bool, len(formatter.comments))
+
+	if n := len(formatter.comments); n > 0 && !positionOf(formatter.comments[n-1]).before(formatter.end) {
+		formatter.end = position{formatter.comments[n-1].Line + 1, 1}
+	}
+
+	return formatter
+}
+
+// offset returns the byte offset of a token in the source.
+func (formatter *formatter) offset(tok token.Token) int {
+	return formatter.lineOffsets[tok.Line-1] + tok.Column - 1
+}
+
+// tokensBefore returns the number of tokens that start before p.
+func (formatter *formatter) tokensBefore(p position) int {
+	return sort.Search(len(formatter.tokens), func(i int) bool {
+		return !positionOf(formatter.tokens[i]).before(p)
+	})
+}
+
+// lastTokenBefore returns the last token that starts before p.
+func (formatter *formatter) lastTokenBefore(p position) token.Token {
+	if i := formatter.tokensBefore(p); i > 0 {
+		return formatter.tokens[i-1]
+	}
+
+	return token.Token{}
+}
+
+// endLine returns the line a token ends on, which differs from the line it
+// starts on for strings spanning several lines.
+func (formatter *formatter) endLine(tok token.Token) int {
+	if tok.Type == token.STRING && tok.Line > 0 {
+		return tok.Line + strings.Count(formatter.stringSource(tok), "\n")
+	}
+
+	return tok.Line
+}
+
+// statements lays out a statement list whose source lies between start and
+// end, together with the comments in that range. Each comment is printed
+// once: before the statement it precedes, with the node it is attached to
+// inside a statement, after the statement whose last line it ends, or at
+// the end of the list. Comments in nested blocks and bracketed lists are
+// left to those, and comments no node takes move before the statement.
+func (formatter *formatter) statements(list []ast.Statement, start, end position) concat {
+	out := concat{}
+	previousLine := 0
+
+	separate := func(line int) {
+		if len(out) > 0 {
+			out = append(out, hardline)
+
+			if line > previousLine+1 {
+				out = append(out, hardline)
+			}
+		}
+	}
+
+	printComments := func(include func(comment token.Token) bool) {
+		for i, comment := range formatter.comments {
+			if !formatter.printed[i] && include(comment) {
+				separate(comment.Line)
+				out = append(out, text(comment.Literal))
+				formatter.printed[i] = true
+				previousLine = comment.Line
+			}
+		}
+	}
+
+	for i, statement := range list {
+		first := formatter.statementStart(statement)
+		next := end
+
+		if i+1 < len(list) {
+			next = formatter.statementStart(list[i+1])
+		}
+
+		last := formatter.lastTokenBefore(next)
+		nested := formatter.nestedRanges(statement)
+
+		formatter.attach(statement, first, positionOf(last))
+		statementDoc := formatter.statement(statement)
+		trailing := formatter.take(formatter.trailing, statement)
+
+		printComments(func(comment token.Token) bool {
+			at := positionOf(comment)
+
+			if !start.before(at) || !at.before(positionOf(last)) {
+				return false
+			}
+
+			for _, block := range nested {
+				if block[0].before(at) && at.before(block[1]) {
+					return false
+				}
+			}
+
+			return true
+		})
+
+		separate(first.line)
+		previousLine = formatter.endLine(last)
+
+		for j, comment := range formatter.comments {
+			at := positionOf(comment)
+
+			if !formatter.printed[j] && comment.Line == previousLine && positionOf(last).before(at) && (next == position{} || at.before(next)) {
+				trailing = append(trailing, comment)
+				formatter.printed[j] = true
+			}
+		}
+
+		for j, comment := range trailing {
+			if j == 0 {
+				statementDoc = concat{statementDoc, text(" "), text(comment.Literal)}
+			} else {
+				statementDoc = concat{statementDoc, hardline, text(comment.Literal)}
+			}
+		}
+
+		out = append(out, statementDoc)
+	}
+
+	printComments(func(comment token.Token) bool {
+		at := positionOf(comment)
+
+		return start.before(at) && at.before(end)
+	})
+
+	return out
+}
+
+func (formatter *formatter) statementStart(statement ast.Statement) position {
+	switch statement := statement.(type) {
+	case *ast.LetStatement:
+		return positionOf(statement.Token)
+	case *ast.ReturnStatement:
+		return positionOf(statement.Token)
+	case *ast.ExpressionStatement:
+		return positionOf(statement.Token)
+	case *ast.ImportStatement:
+		return positionOf(statement.Token)
+	case *ast.ExportStatement:
+		return positionOf(statement.Token)
+	case *ast.ThrowStatement:
+		return positionOf(statement.Token)
+	case *ast.TryStatement:
+		return positionOf(statement.Token)
+	case *ast.DeferStatement:
+		return positionOf(statement.Token)
+	case *ast.SelectStatement:
+		return positionOf(statement.Token)
+	case *ast.StructStatement:
+		return positionOf(statement.Token)
+	case *ast.EnumStatement:
+		return positionOf(statement.Token)
+	default:
+		panic(fmt.Sprintf("formatter: unexpected statement type %T", statement))
+	}
+}
+
+// nestedRanges returns the source ranges of the braced blocks and the
+// bracketed lists in a statement; comments inside them are printed with the
+// block or the list items.
+func (formatter *formatter) nestedRanges(statement ast.Statement) [][2]position {
+	ranges := [][2]position{}
+
+	ast.Inspect(statement, func(node ast.Node) bool {
+		if block, ok := node.(*ast.BlockStatement); ok {
+			if end, ok := formatter.closers[positionOf(block.Token)]; ok && block.Token.Type == token.LBRACE {
+				ranges = append(ranges, [2]position{positionOf(block.Token), end})
+			}
+		}
+
+		if opener, ok := formatter.listOpener(node); ok {
+			ranges = append(ranges, [2]position{opener, formatter.closers[opener]})
+		}
+
+		return true
+	})
+
+	return ranges
+}
+
+// listOpener returns the position of the bracket opening the argument,
+// parameter, array, hash, field, variant or pattern list of node, if it
+// has one.
+func (formatter *formatter) listOpener(node ast.Node) (position, bool) {
+	var at position
+
+	switch node := node.(type) {
+	case *ast.CallExpression:
+		at = positionOf(node.Token)
+	case *ast.ArrayLiteral:
+		at = positionOf(node.Token)
+	case *ast.HashLiteral:
+		at = positionOf(node.Token)
+	case *ast.ArrayPattern:
+		at = positionOf(node.Token)
+	case *ast.HashPattern:
+		at = positionOf(node.Token)
+	case *ast.VariantPattern:
+		at = formatter.following(node.Variant.Token)
+	case *ast.StructStatement:
+		at = formatter.following(node.Name.Token)
+	case *ast.EnumStatement:
+		at = formatter.following(node.Name.Token)
+	case *ast.EnumVariant:
+		at = formatter.following(node.Name.Token)
+	case *ast.FunctionLiteral:
+		// an arrow function starts at its parameters, fn and fn* at the
+		// keyword before them
+		at = positionOf(node.Token)
+
+		if !node.IsArrow {
+			for i := formatter.tokensBefore(at); i < len(formatter.tokens); i++ {
+				if formatter.tokens[i].Type == token.LPAREN {
+					at = positionOf(formatter.tokens[i])
+					break
+				}
+			}
+		}
+	default:
+		return position{}, false
+	}
+
+	i := formatter.tokensBefore(at)
+
+	if i == len(formatter.tokens) || positionOf(formatter.tokens[i]) != at {
+		return position{}, false
+	}
+
+	switch formatter.tokens[i].Type {
+	case token.LPAREN, token.LBRACKET, token.LBRACE:
+		_, ok := formatter.closers[at]
+		return at, ok
+	default:
+		return position{}, false
+	}
+}
+
+// following returns the position of the token after tok.
+func (formatter *formatter) following(tok token.Token) position {
+	if i := formatter.tokensBefore(positionOf(tok)) + 1; i < len(formatter.tokens) {
+		return positionOf(formatter.tokens[i])
+	}
+
+	return position{}
+}
+
+// separators returns the positions of the commas directly between the
+// bracket at opener and its closer, skipping those in nested brackets.
+func (formatter *formatter) separators(opener position) []position {
+	commas := []position{}
+	closer := formatter.closers[opener]
+
+	for i := formatter.tokensBefore(opener) + 1; i < len(formatter.tokens) && positionOf(formatter.tokens[i]).before(closer); i++ {
+		tok := formatter.tokens[i]
+
+		if end, ok := formatter.closers[positionOf(tok)]; ok {
+			i = formatter.tokensBefore(end)
+		} else if tok.Type == token.COMMA {
+			commas = append(commas, positionOf(tok))
+		}
+	}
+
+	return commas
+}
+
+// block lays out a braced block. The comments attached to the block go
+// inside it, before and after its statements.
+func (formatter *formatter) block(block *ast.BlockStatement) doc {
+	start := positionOf(block.Token)
+	end, ok := formatter.closers[start]
+
+	if !ok || block.Token.Type != token.LBRACE {
+		start, end = position{}, position{}
+	}
+
+	lines := []doc{}
+
+	for _, comment := range formatter.take(formatter.leading, block) {
+		lines = append(lines, text(comment.Literal))
+	}
+
+	if statements := formatter.statements(block.Statements, start, end); len(statements) > 0 {
+		lines = append(lines, statements)
+	}
+
+	for _, comment := range formatter.take(formatter.trailing, block) {
+		lines = append(lines, text(comment.Literal))
+	}
+
+	if len(lines) == 0 {
+		return text("{}")
+	}
+
+	return concat{text("{"), indent{hardline, join(lines, hardline)}, hardline, text("}")}
+}
+
+func (formatter *formatter) statement(statement ast.Statement) doc {
+	switch statement := statement.(type) {
+	case *ast.LetStatement:
+		return concat{formatter.let(statement), text(";")}
+
+	case *ast.ReturnStatement:
+		if statement.ReturnValue == nil {
+			return text("return;")
+		}
+
+		return concat{text("return "), formatter.expression(statement.ReturnValue), text(";")}
+
+	case *ast.ExpressionStatement:
+		return concat{formatter.expression(statement.Expression), text(";")}
+
+	case *ast.ImportStatement:
+		out := concat{text("import "), formatter.expression(statement.Path)}
+
+		if statement.Alias != nil {
+			out = append(out, text(" as "), formatter.attached(statement.Alias, text(statement.Alias.Value)))
+		}
+
+		return append(out, text(";"))
+
+	case *ast.ExportStatement:
+		return concat{text("export "), formatter.let(statement.Declaration), text(";")}
+
+	case *ast.ThrowStatement:
+		return concat{text("throw "), formatter.expression(statement.Value), text(";")}
+
+	case *ast.TryStatement:
+		out := concat{text("try "), formatter.block(statement.Block)}
+
+		if statement.Catch != nil {
+			out = append(out, text(" catch "))
+
+			if statement.CatchParameter != nil {
+				// a comment before the parameter goes into the block
+				// rather than between the parentheses
+				formatter.leading[statement.Catch] = append(formatter.leading[statement.CatchParameter], formatter.leading[statement.Catch]...)
+				delete(formatter.leading, statement.CatchParameter)
+
+				out = append(out, text("("), formatter.attached(statement.CatchParameter, text(statement.CatchParameter.Value)), text(") "))
+			}
+
+			out = append(out, formatter.block(statement.Catch))
+		}
+
+		if statement.Finally != nil {
+			out = append(out, text(" finally "), formatter.block(statement.Finally))
+		}
+
+		return out
+
+	case *ast.DeferStatement:
+		return concat{text("defer "), formatter.expression(statement.Call), text(";")}
+
+	case *ast.SelectStatement:
+		cases := []doc{}
+
+		for _, c := range statement.Cases {
+			selectCase := concat{text("case ")}
+
+			if c.Binding != nil {
+				selectCase = append(selectCase, formatter.attached(c.Binding, text(c.Binding.Value)), text(" = "))
+			}
+
+			selectCase = append(selectCase, formatter.expression(c.Operation), text(" "), formatter.block(c.Body))
+			cases = append(cases, formatter.attachedLines(c, selectCase))
+		}
+
+		if statement.Default != nil {
+			cases = append(cases, concat{text("default "), formatter.block(statement.Default)})
+		}
+
+		return concat{text("select {"), indent{hardline, join(cases, hardline)}, hardline, text("}")}
+
+	case *ast.StructStatement:
+		return concat{
+			text("struct "), formatter.attached(statement.Name, text(statement.Name.Value)), text(" "),
+			formatter.bracedItems(statement, formatter.identifiers(statement.Fields)),
+		}
+
+	case *ast.EnumStatement:
+		variants := []doc{}
+
+		for _, variant := range statement.Variants {
+			name := formatter.attached(variant.Name, text(variant.Name.Value))
+
+			if len(variant.Fields) == 0 {
+				variants = append(variants, formatter.attached(variant, name))
+			} else {
+				fields := formatter.items(variant, formatter.identifiers(variant.Fields), "(", ")")
+				variants = append(variants, formatter.attached(variant, concat{name, fields}))
+			}
+		}
+
+		return concat{
+			text("enum "), formatter.attached(statement.Name, text(statement.Name.Value)), text(" "),
+			formatter.bracedItems(statement, variants),
+		}
+
+	default:
+		panic(fmt.Sprintf("formatter: unexpected statement type %T", statement))
+	}
+}
+
+func (formatter *formatter) let(statement *ast.LetStatement) doc {
+	keyword := "let "
+
+	if statement.IsConst() {
+		keyword = "const "
+	}
+
+	return concat{text(keyword), formatter.pattern(statement.Target()), formatter.annotation(statement.Type),
+		text(" = "), formatter.expression(statement.Value)}
+}
+
+// annotation lays out the `: type` of a binding, if it has one. Types are
+// short enough to never be broken across lines.
+func (formatter *formatter) annotation(annotation ast.TypeExpression) doc {
+	if annotation == nil {
+		return concat{}
+	}
+
+	return concat{text(": "), formatter.attached(annotation, text(annotation.String()))}
+}
+
+// Precedences of the expression kinds, mirroring the parser.
+const (
+	_ int = iota
+	lowest
+	pipeline
+	equals
+	lessGreater
+	sum
+	product
+	prefix
+	call
+	index
+	primary
+)
+
+var operatorPrecedences = map[string]int{
+	"==": equals,
+	"!=": equals,
+	"<":  lessGreater,
+	">":  lessGreater,
+	"+":  sum,
+	"-":  sum,
+	"*":  product,
+	"/":  product,
+}
+
+// precedence returns how tightly an expression binds when it appears as an
+// operand. Expressions that extend as far to the right as possible, such
+// as arrow functions and yield, bind loosest.
+func precedence(expression ast.Expression) int {
+	switch expression := expression.(type) {
+	case *ast.InfixExpression:
+		return operatorPrecedences[expression.Operator]
+	case *ast.CallExpression:
+		if expression.Piped {
+			return pipeline
+		}
+
+		return call
+	case *ast.PrefixExpression, *ast.SpawnExpression:
+		return prefix
+	case *ast.MemberExpression:
+		return call
+	case *ast.IndexExpression, *ast.SliceExpression:
+		return index
+	case *ast.FunctionLiteral:
+		if expression.IsArrow {
+			return lowest
+		}
+
+		return primary
+	case *ast.YieldExpression:
+		return lowest
+	default:
+		return primary
+	}
+}
+
+// operand lays out an expression that must bind at least as tightly as
+// minimum, adding parentheses where it does not.
+func (formatter *formatter) operand(expression ast.Expression, minimum int) doc {
+	if precedence(expression) < minimum {
+		return concat{text("("), formatter.expression(expression), text(")")}
+	}
+
+	return formatter.expression(expression)
+}
+
+func (formatter *formatter) expression(expression ast.Expression) doc {
+	return formatter.attached(expression, formatter.bareExpression(expression))
+}
+
+// bareExpression lays out an expression without its attached comments.
+func (formatter *formatter) bareExpression(expression ast.Expression) doc {
+	switch expression := expression.(type) {
+	case *ast.Identifier:
+		return text(expression.Value)
+
+	case *ast.IntegerLiteral:
+		return text(expression.Token.Literal)
+
+	case *ast.Boolean:
+		return text(expression.Token.Literal)
+
+	case *ast.StringLiteral:
+		if expression.Token.Type == token.STRING && expression.Token.Line > 0 {
+			return text(formatter.stringSource(expression.Token))
+		}
+
+		return text(quote(expression.Value))
+
+	case *ast.TemplateLiteral:
+		out := concat{text("`")}
+
+		for _, part := range expression.Parts {
+			if str, ok := part.(*ast.TemplateString); ok {
+				out = append(out, formatter.templateText(str))
+			} else {
+				out = append(out, text("${"), formatter.expression(part), text("}"))
+			}
+		}
+
+		return append(out, text("`"))
+
+	case *ast.PrefixExpression:
+		// -(-x) rather than --x, which reads like a decrement
+		if right, ok := expression.Right.(*ast.PrefixExpression); ok && right.Operator == "-" && expression.Operator == "-" {
+			return concat{text("-("), formatter.expression(right), text(")")}
+		}
+
+		return concat{text(expression.Operator), formatter.operand(expression.Right, prefix)}
+
+	case *ast.InfixExpression:
+		level := operatorPrecedences[expression.Operator]
+
+		return group{
+			formatter.operand(expression.Left, level),
+			text(" " + expression.Operator),
+			breakIndent{space, formatter.operand(expression.Right, level+1)},
+		}
+
+	case *ast.FunctionLiteral:
+		return formatter.function(expression)
+
+	case *ast.CallExpression:
+		if expression.Piped {
+			return formatter.pipeline(expression)
+		}
+
+		return concat{formatter.operand(expression.Function, call), formatter.arguments(expression, expression.Arguments)}
+
+	case *ast.SpreadExpression:
+		return concat{text("..."), formatter.expression(expression.Value)}
+
+	case *ast.NamedArgument:
+		return concat{formatter.attached(expression.Name, text(expression.Name.Value)), text(": "), formatter.expression(expression.Value)}
+
+	case *ast.MemberExpression:
+		return concat{formatter.operand(expression.Object, call), text("."), formatter.attached(expression.Property, text(expression.Property.Value))}
+
+	case *ast.SpawnExpression:
+		return concat{text("spawn "), formatter.operand(expression.Call, prefix)}
+
+	case *ast.YieldExpression:
+		if expression.Value == nil {
+			return text("yield")
+		}
+
+		return concat{text("yield "), formatter.expression(expression.Value)}
+
+	case *ast.ArrayLiteral:
+		return formatter.items(expression, formatter.expressions(expression.Elements), "[", "]")
+
+	case *ast.IndexExpression:
+		return concat{formatter.operand(expression.Left, call), text("["), formatter.expression(expression.Index), text("]")}
+
+	case *ast.SliceExpression:
+		out := concat{formatter.operand(expression.Left, call), text("[")}
+
+		if expression.Low != nil {
+			out = append(out, formatter.expression(expression.Low))
+		}
+
+		out = append(out, text(":"))
+
+		if expression.High != nil {
+			out = append(out, formatter.expression(expression.High))
+		}
+
+		if expression.Step != nil {
+			out = append(out, text(":"), formatter.expression(expression.Step))
+		}
+
+		return append(out, text("]"))
+
+	case *ast.HashLiteral:
+		pairs := []doc{}
+
+		for _, pair := range expression.Pairs {
+			pairs = append(pairs, concat{formatter.expression(pair.Key), text(": "), formatter.expression(pair.Value)})
+		}
+
+		return formatter.items(expression, pairs, "{", "}")
+
+	case *ast.MatchExpression:
+		arms := []doc{}
+
+		for _, arm := range expression.Arms {
+			arms = append(arms, formatter.attachedLines(arm, concat{formatter.pattern(arm.Pattern), text(" => "), formatter.body(arm.Body), text(",")}))
+		}
+
+		return concat{
+			text("match "), formatter.expression(expression.Subject), text(" {"),
+			indent{hardline, join(arms, hardline)}, hardline, text("}"),
+		}
+
+	case *ast.IfExpression:
+		out := concat{text("if ("), formatter.expression(expression.Condition), text(") "), formatter.block(expression.Consequence)}
+
+		// the alternative of an else if is the nested if itself
+		if expression.Alternative != nil {
+			out = append(out, text(" else "), formatter.body(expression.Alternative))
+		}
+
+		return out
+
+	default:
+		panic(fmt.Sprintf("formatter: unexpected expression type %T", expression))
+	}
+}
+
+func (formatter *formatter) expressions(expressions []ast.Expression) []doc {
+	docs := []doc{}
+
+	for _, expression := range expressions {
+		docs = append(docs, formatter.expression(expression))
+	}
+
+	return docs
+}
+
+// arguments lays out the arguments of call between its parentheses, which
+// leave out the piped first argument of a pipeline stage.
+func (formatter *formatter) arguments(call *ast.CallExpression, arguments []ast.Expression) doc {
+	return formatter.items(call, formatter.expressions(arguments), "(", ")")
+}
+
+func (formatter *formatter) function(function *ast.FunctionLiteral) doc {
+	parameters := []doc{}
+
+	for _, parameter := range function.Parameters {
+		binding := concat{formatter.pattern(parameter.Pattern), formatter.annotation(parameter.Type)}
+
+		if parameter.Rest {
+			parameters = append(parameters, formatter.attached(parameter, concat{text("..."), binding}))
+		} else if parameter.Default != nil {
+			parameters = append(parameters, formatter.attached(parameter, concat{binding, text(" = "), formatter.expression(parameter.Default)}))
+		} else {
+			parameters = append(parameters, formatter.attached(parameter, binding))
+		}
+	}
+
+	if function.IsArrow {
+		return concat{formatter.items(function, parameters, "(", ")"), text(" => "), formatter.body(function.Body)}
+	}
+
+	keyword := "fn"
+
+	if function.IsGenerator {
+		keyword = "fn*"
+	}
+
+	var result doc = text(" ")
+
+	if function.ReturnType != nil {
+		result = concat{text(" -> "), formatter.attached(function.ReturnType, text(function.ReturnType.String())), text(" ")}
+	}
+
+	return concat{text(keyword), formatter.items(function, parameters, "(", ")"), result, formatter.block(function.Body)}
+}
+
+// body lays out the body of an arrow function or match arm, which is
+// either a braced block or a single expression.
+func (formatter *formatter) body(body *ast.BlockStatement) doc {
+	if body.Token.Type != token.LBRACE && len(body.Statements) == 1 {
+		if statement, ok := body.Statements[0].(*ast.ExpressionStatement); ok {
+			return formatter.attached(body, formatter.attached(statement, formatter.expression(statement.Expression)))
+		}
+	}
+
+	return formatter.block(body)
+}
+
+// pipeline lays out a chain such as xs |> map(f) |> sum with one stage per
+// line when the chain does not fit.
+func (formatter *formatter) pipeline(expression *ast.CallExpression) doc {
+	stages := []doc{}
+	var head ast.Expression = expression
+
+	for {
+		stage, ok := head.(*ast.CallExpression)
+
+		if !ok || !stage.Piped || len(stage.Arguments) == 0 {
+			break
+		}
+
+		var stageDoc doc
+
+		if callee, ok := stage.Function.(*ast.CallExpression); ok && !callee.Piped && stage.Token.Type == token.PIPE {
+			// a call used as the callee keeps its parentheses: x |> (f(y))
+			stageDoc = concat{text("("), formatter.expression(callee), text(")")}
+		} else if stage.Token.Type == token.PIPE {
+			stageDoc = formatter.operand(stage.Function, pipeline+1)
+		} else {
+			stageDoc = concat{formatter.operand(stage.Function, call), formatter.arguments(stage, stage.Arguments[1:])}
+		}
+
+		stageDoc = concat{space, text("|> "), stageDoc}
+
+		// the comments of the whole pipeline go around it rather than its
+		// last stage
+		if stage != expression {
+			stageDoc = formatter.attached(stage, stageDoc)
+		}
+
+		stages = append([]doc{stageDoc}, stages...)
+		head = stage.Arguments[0]
+	}
+
+	return group{formatter.operand(head, pipeline), breakIndent(stages)}
+}
+
+func (formatter *formatter) pattern(pattern ast.Pattern) doc {
+	return formatter.attached(pattern, formatter.barePattern(pattern))
+}
+
+// barePattern lays out a pattern without its attached comments.
+func (formatter *formatter) barePattern(pattern ast.Pattern) doc {
+	switch pattern := pattern.(type) {
+	case *ast.Identifier:
+		return text(pattern.Value)
+
+	case *ast.ArrayPattern:
+		elements := []doc{}
+
+		for _, element := range pattern.Elements {
+			elements = append(elements, formatter.pattern(element))
+		}
+
+		if pattern.Rest != nil {
+			elements = append(elements, concat{text("..."), formatter.attached(pattern.Rest, text(pattern.Rest.Value))})
+		}
+
+		return formatter.items(pattern, elements, "[", "]")
+
+	case *ast.HashPattern:
+		pairs := []doc{}
+
+		for _, pair := range pattern.Pairs {
+			if value, ok := pair.Value.(*ast.Identifier); ok && value.Value == pair.Key.Value {
+				pairs = append(pairs, text(pair.Key.Value))
+			} else {
+				pairs = append(pairs, concat{text(pair.Key.Value + ": "), formatter.pattern(pair.Value)})
+			}
+		}
+
+		if pattern.Rest != nil {
+			pairs = append(pairs, concat{text("..."), formatter.attached(pattern.Rest, text(pattern.Rest.Value))})
+		}
+
+		return formatter.items(pattern, pairs, "{", "}")
+
+	case *ast.VariantPattern:
+		name := pattern.Variant.Value
+
+		if pattern.Enum != nil {
+			name = pattern.Enum.Value + "." + name
+		}
+
+		// a lower-case variant without bindings needs the parentheses to
+		// be told apart from a binding
+		if len(pattern.Bindings) == 0 && (pattern.Enum != nil || isUpper(name)) {
+			return text(name)
+		}
+
+		bindings := []doc{}
+
+		for _, binding := range pattern.Bindings {
+			bindings = append(bindings, formatter.pattern(binding))
+		}
+
+		return concat{text(name), formatter.items(pattern, bindings, "(", ")")}
+
+	case *ast.LiteralPattern:
+		return formatter.expression(pattern.Value)
+
+	default:
+		panic(fmt.Sprintf("formatter: unexpected pattern type %T", pattern))
+	}
+}
+
+// list lays out comma separated items between open and close, all on one
+// line if they fit and one per line otherwise.
+func list(items []doc, open, close string) doc {
+	if len(items) == 0 {
+		return text(open + close)
+	}
+
+	return group{text(open), breakIndent{softline, join(items, text(","), space)}, softline, text(close)}
+}
+
+// items lays out the bracketed list of node like list, together with the
+// comments between its brackets. Each is printed after the item whose line
+// it ends, before the item it precedes or is nested in, or before the
+// closing bracket, and breaks the list over several lines.
+func (formatter *formatter) items(node ast.Node, items []doc, open, close string) doc {
+	if commented, ok := formatter.commentedItems(node, items, open, close); ok {
+		return commented
+	}
+
+	return list(items, open, close)
+}
+
+// bracedItems is items for the body of a struct or an enum, laid out like
+// braced when it holds no comments.
+func (formatter *formatter) bracedItems(node ast.Node, items []doc) doc {
+	if commented, ok := formatter.commentedItems(node, items, "{", "}"); ok {
+		return commented
+	}
+
+	return braced(items, "{", "}")
+}
+
+// commentedItems lays out the list of node one item per line with the
+// comments between its brackets, and reports whether there are any.
+func (formatter *formatter) commentedItems(node ast.Node, items []doc, open, close string) (doc, bool) {
+	opener, ok := formatter.listOpener(node)
+
+	if !ok {
+		return nil, false
+	}
+
+	closer := formatter.closers[opener]
+	separators := formatter.separators(opener)
+	leading := make([][]doc, len(items)+1) // the last before the closing bracket
+	trailing := make([]doc, len(items))
+	found := false
+
+	for _, comment := range formatter.take(formatter.opening, node) {
+		leading[0] = append(leading[0], text(comment.Literal))
+		found = true
+	}
+
+	segmentStart := func(item int) position {
+		if item == 0 {
+			return opener
+		}
+
+		return separators[item-1]
+	}
+
+	for i, comment := range formatter.comments {
+		at := positionOf(comment)
+
+		if formatter.printed[i] || !opener.before(at) || !at.before(closer) {
+			continue
+		}
+
+		formatter.printed[i] = true
+		found = true
+
+		// the number of commas before the comment is the index of the item
+		// it is in or precedes
+		item := sort.Search(len(separators), func(j int) bool { return at.before(separators[j]) })
+		previous := formatter.lastTokenBefore(at)
+
+		if comment.Line == formatter.endLine(previous) && positionOf(previous) != opener {
+			if item > 0 && positionOf(previous) == separators[item-1] {
+				item--
+			}
+
+			if item < len(items) && trailing[item] == nil {
+				trailing[item] = text(comment.Literal)
+				continue
+			}
+		}
+
+		// a comment after the start of the last item follows all items
+		if item == len(items)-1 && segmentStart(item).before(positionOf(previous)) {
+			item++
+		}
+
+		item = min(item, len(items))
+		leading[item] = append(leading[item], text(comment.Literal))
+	}
+
+	if !found {
+		return nil, false
+	}
+
+	body := concat{}
+
+	for i := range leading {
+		for _, comment := range leading[i] {
+			body = append(body, hardline, comment)
+		}
+
+		if i == len(items) {
+			break
+		}
+
+		body = append(body, hardline, items[i])
+
+		if i+1 < len(items) {
+			body = append(body, text(","))
+		}
+
+		if trailing[i] != nil {
+			body = append(body, text(" "), trailing[i])
+		}
+	}
+
+	return concat{text(open), indent(body), hardline, text(close)}, true
+}
+
+// braced is like list, with spaces inside the braces when on one line.
+func braced(items []doc, open, close string) doc {
+	if len(items) == 0 {
+		return text(open + close)
+	}
+
+	return group{text(open), breakIndent{space, join(items, text(","), space)}, space, text(close)}
+}
+
+func (formatter *formatter) identifiers(list []*ast.Identifier) []doc {
+	docs := []doc{}
+
+	for _, identifier := range list {
+		docs = append(docs, formatter.attached(identifier, text(identifier.Value)))
+	}
+
+	return docs
+}
+
+func isUpper(name string) bool {
+	first, _ := utf8.DecodeRuneInString(name)
+
+	return unicode.IsUpper(first)
+}
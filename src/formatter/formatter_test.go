@@ -0,0 +1,375 @@
+package formatter
+
+import (
+	"monkey/ast"
+	"monkey/lexer"
+	"monkey/parser"
+	"monkey/token"
+	"strings"
+	"testing"
+)
+
+func format(t *testing.T, input string, width int) string {
+	t.Helper()
+
+	output, err := Source([]byte(input), Options{Width: width})
+
+	if err != nil {
+		t.Fatalf("Source(%q) failed: %v", input, err)
+	}
+
+	return string(output)
+}
+
+func TestLayout(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let   x=1+2*3;let y = fn(a,b){return a+b};", `let x = 1 + 2 * 3;
+let y = fn(a, b) {
+    return a + b;
+};
+`},
+		{"let f = fn(){};", "let f = fn() {};\n"},
+		{"let g = fn*(x){yield x};", "let g = fn*(x) {\n    yield x;\n};\n"},
+		{"let add = (a,b)=>a+b;", "let add = (a, b) => a + b;\n"},
+		{"let n:int=1;let f=fn(a:[int],b:bool=true,...c:[any])->{string:int}{{}};", `let n: int = 1;
+let f = fn(
+    a: [int],
+    b: bool = true,
+    ...c: [any]
+) -> {string: int} {
+    {};
+};
+`},
+		{"let v = match s { Circle(r) => r, Square => { 0 } };", `let v = match s {
+    Circle(r) => r,
+    Square => {
+        0;
+    },
+};
+`},
+		{"callSomething(firstArgument, secondArgument, thirdArgument, fourthArgument);", `callSomething(
+    firstArgument,
+    secondArgument,
+    thirdArgument,
+    fourthArgument
+);
+`},
+		{"let total = items |> filter((item) => item.active) |> map((item) => item.price) |> sum;", `let total = items
+    |> filter((item) => item.active)
+    |> map((item) => item.price)
+    |> sum;
+`},
+		{"try { a() } catch (e) { b(e) } finally { c() }\nstruct P { x, y }\nenum S { C(r), Q }", `try {
+    a();
+} catch (e) {
+    b(e);
+} finally {
+    c();
+}
+struct P { x, y }
+enum S { C(r), Q }
+`},
+		{`let h = {"a": 1, "b": [1,2,3]}; let {a, b: [c], ...d} = h;`, `let h = {"a": 1, "b": [1, 2, 3]};
+let {a, b: [c], ...d} = h;
+`},
+		{`let s = r"C:\dir"; let t = "a\"b\n";`, `let s = r"C:\dir";
+let t = "a\"b\n";
+`},
+		{"let v = if (a) { 1 } else if (b) { 2 } else { 3 };", `let v = if (a) {
+    1;
+} else if (b) {
+    2;
+} else {
+    3;
+};
+`},
+		{"(a - b) - (c - d); -(-x); (-a)[0];", "a - b - (c - d);\n-(-x);\n(-a)[0];\n"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if output := format(t, tt.input, 40); output != tt.expected {
+			t.Errorf("wrong layout for %q.\nexpected:\n%s\ngot:\n%s", tt.input, tt.expected, output)
+		}
+	}
+}
+
+func TestComments(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"// header\n\nlet a = 1; // trailing\n\n\n\nlet b = 2;\n// end", `// header
+
+let a = 1; // trailing
+
+let b = 2;
+// end
+`},
+		{"let f = fn(x) {\n  // inside\n  x\n  // last\n};", `let f = fn(x) {
+    // inside
+    x;
+    // last
+};
+`},
+		{"let f = fn() {\n// only\n};", "let f = fn() {\n    // only\n};\n"},
+		{"if (a) { 1 } else { // other\n2 }", `if (a) {
+    1;
+} else {
+    // other
+    2;
+};
+`},
+		{"let n = 1 + // one\n  2;", `let n = 1 + // one
+    2;
+`},
+	}
+
+	for _, tt := range tests {
+		if output := format(t, tt.input, 80); output != tt.expected {
+			t.Errorf("wrong layout for %q.\nexpected:\n%s\ngot:\n%s", tt.input, tt.expected, output)
+		}
+	}
+}
+
+func TestListComments(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let arr = [1, // one\n  2,\n  // before three\n  3];", `let arr = [
+    1, // one
+    2,
+    // before three
+    3
+];
+`},
+		{"let h = {\n  \"a\": 1, // one\n  \"b\": f(x, // inner\n    y)\n};", `let h = {
+    "a": 1, // one
+    "b": f(
+        x, // inner
+        y
+    )
+};
+`},
+		{"let f = fn( // first\n  a,\n  b // last\n) { a };", `let f = fn(
+    // first
+    a,
+    b // last
+) {
+    a;
+};
+`},
+		{"xs |> map((x) => x, // identity\n  1);\nlog(a, b\n  // after\n);", `xs |> map(
+    (x) => x, // identity
+    1
+);
+log(
+    a,
+    b
+    // after
+);
+`},
+	}
+
+	for _, tt := range tests {
+		output := format(t, tt.input, 80)
+
+		if output != tt.expected {
+			t.Errorf("wrong layout for %q.\nexpected:\n%s\ngot:\n%s", tt.input, tt.expected, output)
+		}
+
+		if again := format(t, output, 80); again != output {
+			t.Errorf("formatting is not idempotent for %q.\nfirst:\n%s\nsecond:\n%s", tt.input, output, again)
+		}
+	}
+}
+
+func TestNodeComments(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let n = 1 +\n  // own line\n  2;\nlet total = price // base\n  + tax;", `let n = 1 +
+    // own line
+    2;
+let total = price + // base
+    tax;
+`},
+		{"struct P { x, // the x\n  y }\nenum S // shapes\n{ C(r, // radius\n  s), Q }", `struct P {
+    x, // the x
+    y
+}
+enum S {
+    // shapes
+    C(
+        r, // radius
+        s
+    ),
+    Q
+}
+`},
+		{"let [a, // first\n  ...rest] = xs;\nlet v = match s { Circle(r, // radius\n  h) => r, _ => // other\n  0 };", `let [
+    a, // first
+    ...rest
+] = xs;
+let v = match s {
+    Circle(
+        r, // radius
+        h
+    ) => r,
+    _ => // other
+        0,
+};
+`},
+		{"let s = `a${x // the x\n}b${ // the y\n  y}`;", "let s = `a${x // the x\n}b${ // the y\n    y}`;\n"},
+		{"let f = fn() {\n  return // why\n    x;\n};\nreturn // nothing\n;", `let f = fn() {
+    return // why
+        x;
+};
+return; // nothing
+`},
+		{"try // first\n{ a() } // after a\ncatch // before e\n(e) { b() }", `try {
+    // first
+    a();
+    // after a
+} catch (e) {
+    // before e
+    b();
+}
+`},
+		{"let v = match s {\n  A => 1, // one\n  // before b\n  B => 2\n  // end\n};", `let v = match s {
+    A => 1, // one
+    // before b
+    B => 2,
+    // end
+};
+`},
+		{"let r = xs // source\n  |> f // first\n  |> g;\nlet x = xs[i // index\n] // value\n;", `let r = xs // source
+    |> f // first
+    |> g;
+let x = xs[i // index
+]; // value
+`},
+	}
+
+	for _, tt := range tests {
+		output := format(t, tt.input, 80)
+
+		if output != tt.expected {
+			t.Errorf("wrong layout for %q.\nexpected:\n%s\ngot:\n%s", tt.input, tt.expected, output)
+		}
+
+		for _, width := range []int{20, 80} {
+			first := format(t, tt.input, width)
+
+			if again := format(t, first, width); again != first {
+				t.Errorf("formatting is not idempotent at width %d for %q.\nfirst:\n%s\nsecond:\n%s", width, tt.input, first, again)
+			}
+
+			if before, after := parse(t, tt.input), parse(t, first); !ast.Equal(before, after, ast.EqualOptions{IgnorePositions: true}) {
+				t.Errorf("formatting changed the program at width %d for %q.\nafter:\n%s", width, tt.input, first)
+			}
+		}
+	}
+}
+
+func TestWidth(t *testing.T) {
+	input := "let list = [first, second, third];"
+
+	if output := format(t, input, 80); output != input+"\n" {
+		t.Errorf("expected list to stay on one line. got:\n%s", output)
+	}
+
+	expected := "let list = [\n    first,\n    second,\n    third\n];\n"
+
+	if output := format(t, input, 20); output != expected {
+		t.Errorf("expected list to break.\nexpected:\n%s\ngot:\n%s", expected, output)
+	}
+}
+
+func TestIdempotent(t *testing.T) {
+	inputs := []string{
+		`
+// Package header comment.
+import "strings" as str;
+
+let greet = fn(name, greeting = "Hello", ...rest) {
+    defer log("done"); // always
+    try { check(name) } catch (err) { throw err }
+    return ` + "`${greeting}, ${name}!`" + `;
+};
+
+
+export let shout = (text) => text |> str.upper |> append("!");
+let [first, ...others] = [1, 2, 3];
+let {x, y: [z], ...more} = {"x": 1, "y": [2]};
+select { case v = recv(ch) { v } default { 0 } }
+enum Shape { Circle(radius), Rect(width, height), Empty }
+let area = fn(shape) { match shape { Circle(r) => 3 * r * r, Rect(w, h) => w * h, Shape.Empty => 0, } };
+spawn worker(queue[1:len(queue)], ...options, limit: 10);
+let gen = fn*() { yield; yield 1 };
+if (first < 2) { first } else if (others) { 0 } else { -1 };
+`,
+		"a |> (b |> c);\nx |> (f(y));\n((a, b) => a)(1, 2);\nspawn (x |> f);\n(a < b) == (c > d);\n!(-x);\n",
+		`let s = """multi
+line""";
+let t = r"raw \n";
+` + "let u = `outer ${`inner ${x}`} done`;",
+	}
+
+	for _, input := range inputs {
+		for _, width := range []int{20, 40, 80} {
+			first := format(t, input, width)
+			second := format(t, first, width)
+
+			if first != second {
+				t.Errorf("formatting is not idempotent at width %d.\nfirst:\n%s\nsecond:\n%s", width, first, second)
+			}
+
+			before, after := parse(t, input), parse(t, first)
+
+			if !ast.Equal(before, after, ast.EqualOptions{IgnorePositions: true}) {
+				t.Errorf("formatting changed the program at width %d.\nbefore=%q\nafter= %q", width, before.String(), after.String())
+			}
+		}
+	}
+}
+
+func parse(t *testing.T, input string) *ast.Program {
+	t.Helper()
+
+	newParser := parser.NewParser(lexer.NewLexer(input))
+	program := newParser.ParseProgram()
+
+	if errors := newParser.Errors(); len(errors) > 0 {
+		t.Fatalf("parser errors for %q: %v", input, errors)
+	}
+
+	// an expression statement records its first token, which changes when
+	// the formatter drops redundant parentheses around its start
+	ast.Inspect(program, func(node ast.Node) bool {
+		if statement, ok := node.(*ast.ExpressionStatement); ok {
+			statement.Token = token.Token{}
+		}
+
+		return true
+	})
+
+	return program
+}
+
+func TestParseErrors(t *testing.T) {
+	_, err := Source([]byte("let = 5;"), Options{})
+
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+
+	if !strings.Contains(err.Error(), "expected next token to be IDENT") {
+		t.Errorf("wrong error. got=%q", err.Error())
+	}
+}
@@ -0,0 +1,58 @@
+package formatter
+
+import (
+	"monkey/ast"
+	"monkey/token"
+	"strings"
+)
+
+// stringSource returns a string literal as it is spelled in the source,
+// so that raw and triple-quoted strings keep their form.
+func (formatter *formatter) stringSource(tok token.Token) string {
+	source := formatter.source[formatter.offset(tok):]
+
+	switch {
+	case strings.HasPrefix(source, `r"`):
+		if end := strings.IndexByte(source[2:], '"'); end >= 0 {
+			return source[:end+3]
+		}
+	case strings.HasPrefix(source, `"""`):
+		if end := strings.Index(source[3:], `"""`); end >= 0 {
+			return source[:end+6]
+		}
+	default:
+		for i := 1; i < len(source); i++ {
+			switch source[i] {
+			case '\\':
+				i++
+			case '"':
+				return source[:i+1]
+			}
+		}
+	}
+
+	return source
+}
+
+// templateText returns the text of a template literal as it is spelled in
+// the source, up to the ${ or backtick that follows it.
+func (formatter *formatter) templateText(str *ast.TemplateString) doc {
+	if str.Token.Line == 0 {
+		return text(str.String())
+	}
+
+	next := formatter.tokensBefore(positionOf(str.Token)) + 1
+
+	if next >= len(formatter.tokens) {
+		return text(str.String())
+	}
+
+	return text(formatter.source[formatter.offset(str.Token):formatter.offset(formatter.tokens[next])])
+}
+
+var quoter = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`, "\r", `\r`)
+
+// quote spells a string value as a double-quoted literal.
+func quote(value string) string {
+	return `"` + quoter.Replace(value) + `"`
+}
@@ -1,27 +1,64 @@
 package lexer
 
-import "monkey/token"
+import (
+	"fmt"
+	"monkey/token"
+	"strings"
+)
 
 type Lexer struct {
 	input        string
 	position     int  // current position in input (points to current char)
 	readPosition int  // current reading position in input (after current char)
 	currentChar  byte // current char under examination
+	line         int  // line of currentChar
+	column       int  // column of currentChar
+
+	templates []*templateState // open template literals, innermost last
+	comments  []token.Token
+	errors    []string
 }
 
 /* Lexer Constructor */
 func NewLexer(input string) *Lexer {
-	lexer := &Lexer{input: input}
+	lexer := &Lexer{input: input, line: 1}
 	lexer.readChar()
 
 	return lexer
 }
 
+func (lexer *Lexer) Errors() []string {
+	return lexer.errors
+}
+
+// Comments returns the // comments skipped so far, in source order. Their
+// literal includes the leading slashes but not the line break.
+func (lexer *Lexer) Comments() []token.Token {
+	return lexer.comments
+}
+
 func (lexer *Lexer) NextToken() token.Token {
-	var tok token.Token
+	if template := lexer.currentTemplate(); template != nil && template.inText {
+		return lexer.readTemplateText(template)
+	}
 
 	lexer.skipWhitespace()
 
+	for lexer.currentChar == '/' && lexer.peekChar() == '/' {
+		lexer.readComment()
+		lexer.skipWhitespace()
+	}
+
+	line, column := lexer.line, lexer.column
+	tok := lexer.readToken(line, column)
+	tok.Line, tok.Column = line, column
+
+	return tok
+}
+
+func (lexer *Lexer) readToken(line, column int) token.Token {
+	var tok token.Token
+
 	switch lexer.currentChar {
 	case '=':
 		if lexer.peekChar() == '=' {
@@ -29,13 +66,25 @@ func (lexer *Lexer) NextToken() token.Token {
 			lexer.readChar()
 			literal := string(currentChar) + string(lexer.currentChar)
 			tok = token.Token{Type: token.EQ, Literal: literal}
+		} else if lexer.peekChar() == '>' {
+			currentChar := lexer.currentChar
+			lexer.readChar()
+			literal := string(currentChar) + string(lexer.currentChar)
+			tok = token.Token{Type: token.ARROW, Literal: literal}
 		} else {
 			tok = newToken(token.ASSIGN, lexer.currentChar)
 		}
 	case '+':
 		tok = newToken(token.PLUS, lexer.currentChar)
 	case '-':
-		tok = newToken(token.MINUS, lexer.currentChar)
+		if lexer.peekChar() == '>' {
+			currentChar := lexer.currentChar
+			lexer.readChar()
+			literal := string(currentChar) + string(lexer.currentChar)
+			tok = token.Token{Type: token.THIN_ARROW, Literal: literal}
+		} else {
+			tok = newToken(token.MINUS, lexer.currentChar)
+		}
 	case '!':
 		if lexer.peekChar() == '=' {
 			currentChar := lexer.currentChar
@@ -53,23 +102,98 @@ func (lexer *Lexer) NextToken() token.Token {
 		tok = newToken(token.LT, lexer.currentChar)
 	case '>':
 		tok = newToken(token.GT, lexer.currentChar)
+	case '|':
+		if lexer.peekChar() == '>' {
+			currentChar := lexer.currentChar
+			lexer.readChar()
+			literal := string(currentChar) + string(lexer.currentChar)
+			tok = token.Token{Type: token.PIPE, Literal: literal}
+		} else {
+			tok = newToken(token.ILLEGAL, lexer.currentChar)
+		}
 	case ';':
 		tok = newToken(token.SEMICOLON, lexer.currentChar)
 	case ',':
 		tok = newToken(token.COMMA, lexer.currentChar)
+	case ':':
+		tok = newToken(token.COLON, lexer.currentChar)
+	case '.':
+		if lexer.peekChar() == '.' && lexer.peekCharAt(2) == '.' {
+			lexer.readChar()
+			lexer.readChar()
+			tok = token.Token{Type: token.ELLIPSIS, Literal: "..."}
+		} else {
+			tok = newToken(token.DOT, lexer.currentChar)
+		}
 	case '{':
+		if template := lexer.currentTemplate(); template != nil {
+			template.braces++
+		}
+
 		tok = newToken(token.LBRACE, lexer.currentChar)
 	case '}':
 		tok = newToken(token.RBRACE, lexer.currentChar)
+
+		if template := lexer.currentTemplate(); template != nil {
+			if template.braces == 0 {
+				template.inText = true
+				tok.Type = token.TEMPLATE_EXPR_END
+			} else {
+				template.braces--
+			}
+		}
+	case '`':
+		lexer.templates = append(lexer.templates, &templateState{inText: true, line: lexer.line, column: lexer.column})
+		tok = newToken(token.TEMPLATE_START, lexer.currentChar)
 	case '(':
 		tok = newToken(token.LPAREN, lexer.currentChar)
 	case ')':
 		tok = newToken(token.RPAREN, lexer.currentChar)
+	case '[':
+		tok = newToken(token.LBRACKET, lexer.currentChar)
+	case ']':
+		tok = newToken(token.RBRACKET, lexer.currentChar)
+	case '"':
+		var literal string
+		var ok bool
+
+		if lexer.peekChar() == '"' && lexer.peekCharAt(2) == '"' {
+			literal, ok = lexer.readTripleQuotedString()
+		} else {
+			literal, ok = lexer.readString()
+		}
+
+		tok = token.Token{Type: token.STRING, Literal: literal}
+
+		if !ok {
+			lexer.errorf(line, column, "unterminated string literal")
+			tok = token.Token{Type: token.ILLEGAL, Literal: "\"" + literal}
+
+			return tok
+		}
 	case 0:
+		if template := lexer.currentTemplate(); template != nil {
+			lexer.errorf(template.exprLine, template.exprColumn, "unterminated ${ in template literal")
+			lexer.templates = nil
+
+			return token.Token{Type: token.ILLEGAL, Literal: ""}
+		}
+
 		tok.Literal = ""
 		tok.Type = token.EOF
 	default:
-		if isLetter(lexer.currentChar) {
+		if lexer.currentChar == 'r' && lexer.peekChar() == '"' {
+			lexer.readChar()
+			literal, ok := lexer.readRawString()
+			tok = token.Token{Type: token.STRING, Literal: literal}
+
+			if !ok {
+				lexer.errorf(line, column, "unterminated raw string literal")
+				tok = token.Token{Type: token.ILLEGAL, Literal: "r\"" + literal}
+
+				return tok
+			}
+		} else if isLetter(lexer.currentChar) {
 			tok.Literal = lexer.readIdentifier()
 			tok.Type = token.LookupIdent(tok.Literal)
 
@@ -95,7 +219,26 @@ func (lexer *Lexer) skipWhitespace() {
 	}
 }
 
+func (lexer *Lexer) readComment() {
+	tok := token.Token{Type: token.COMMENT, Line: lexer.line, Column: lexer.column}
+	position := lexer.position
+
+	for lexer.currentChar != '\n' && lexer.currentChar != 0 {
+		lexer.readChar()
+	}
+
+	tok.Literal = strings.TrimRight(lexer.input[position:lexer.position], " \t\r")
+	lexer.comments = append(lexer.comments, tok)
+}
+
 func (lexer *Lexer) readChar() {
+	if lexer.currentChar == '\n' {
+		lexer.line++
+		lexer.column = 0
+	}
+
+	lexer.column++
+
 	if lexer.readPosition >= len(lexer.input) {
 		lexer.currentChar = 0
 	} else {
@@ -114,6 +257,16 @@ func (lexer *Lexer) peekChar() byte {
 	}
 }
 
+func (lexer *Lexer) peekCharAt(offset int) byte {
+	position := lexer.position + offset
+
+	if position >= len(lexer.input) {
+		return 0
+	}
+
+	return lexer.input[position]
+}
+
 func (lexer *Lexer) readIdentifier() string {
 	position := lexer.position
 
@@ -124,6 +277,33 @@ func (lexer *Lexer) readIdentifier() string {
 	return lexer.input[position:lexer.position]
 }
 
+// readString reads a double-quoted string and resolves its escape
+// sequences. It reports false when the input ends before the closing quote.
+func (lexer *Lexer) readString() (string, bool) {
+	var out []byte
+
+	for {
+		lexer.readChar()
+
+		switch lexer.currentChar {
+		case '"':
+			return string(out), true
+		case 0:
+			return string(out), false
+		case '\\':
+			lexer.readChar()
+
+			if lexer.currentChar == 0 {
+				return string(out), false
+			}
+
+			out = append(out, unescape(lexer.currentChar))
+		default:
+			out = append(out, lexer.currentChar)
+		}
+	}
+}
+
 func (lexer *Lexer) readNumber() string {
 	position := lexer.position
 
@@ -142,6 +322,40 @@ func isDigit(currentChar byte) bool {
 	return '0' <= currentChar && currentChar <= '9'
 }
 
+func unescape(currentChar byte) byte {
+	switch currentChar {
+	case 'n':
+		return '\n'
+	case 't':
+		return '\t'
+	case 'r':
+		return '\r'
+	default:
+		return currentChar
+	}
+}
+
 func newToken(tokenType token.TokenType, currentChar byte) token.Token {
 	return token.Token{Type: tokenType, Literal: string(currentChar)}
 }
+
+// Clone returns an independent copy of the lexer, so that callers can scan
+// ahead without consuming input.
+func (lexer *Lexer) Clone() *Lexer {
+	clone := *lexer
+	clone.templates = make([]*templateState, len(lexer.templates))
+	clone.comments = append([]token.Token(nil), lexer.comments...)
+	clone.errors = append([]string(nil), lexer.errors...)
+
+	for i, template := range lexer.templates {
+		state := *template
+		clone.templates[i] = &state
+	}
+
+	return &clone
+}
+
+func (lexer *Lexer) errorf(line, column int, format string, args ...interface{}) {
+	message := fmt.Sprintf("%d:%d: %s", line, column, fmt.Sprintf(format, args...))
+	lexer.errors = append(lexer.errors, message)
+}
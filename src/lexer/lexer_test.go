@@ -124,3 +124,502 @@ if (5 < 10) {
 		}
 	}
 }
+
+type expectedToken struct {
+	expectedType    token.TokenType
+	expectedLiteral string
+}
+
+func testTokens(t *testing.T, input string, tests []expectedToken) {
+	t.Helper()
+
+	newLexer := NewLexer(input)
+
+	for i, testToken := range tests {
+		token := newLexer.NextToken()
+
+		if token.Type != testToken.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, testToken.expectedType, token.Type)
+		}
+
+		if token.Literal != testToken.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, testToken.expectedLiteral, token.Literal)
+		}
+	}
+}
+
+func TestDestructuringTokens(t *testing.T) {
+	input := `let [a, ...rest] = arr;
+let {name, age: years} = person;
+..`
+
+	testTokens(t, input, []expectedToken{
+		{token.LET, "let"},
+		{token.LBRACKET, "["},
+		{token.IDENT, "a"},
+		{token.COMMA, ","},
+		{token.ELLIPSIS, "..."},
+		{token.IDENT, "rest"},
+		{token.RBRACKET, "]"},
+		{token.ASSIGN, "="},
+		{token.IDENT, "arr"},
+		{token.SEMICOLON, ";"},
+		{token.LET, "let"},
+		{token.LBRACE, "{"},
+		{token.IDENT, "name"},
+		{token.COMMA, ","},
+		{token.IDENT, "age"},
+		{token.COLON, ":"},
+		{token.IDENT, "years"},
+		{token.RBRACE, "}"},
+		{token.ASSIGN, "="},
+		{token.IDENT, "person"},
+		{token.SEMICOLON, ";"},
+		{token.DOT, "."},
+		{token.DOT, "."},
+		{token.EOF, ""},
+	})
+}
+
+func TestArrowToken(t *testing.T) {
+	testTokens(t, "(a, b) => a == b", []expectedToken{
+		{token.LPAREN, "("},
+		{token.IDENT, "a"},
+		{token.COMMA, ","},
+		{token.IDENT, "b"},
+		{token.RPAREN, ")"},
+		{token.ARROW, "=>"},
+		{token.IDENT, "a"},
+		{token.EQ, "=="},
+		{token.IDENT, "b"},
+		{token.EOF, ""},
+	})
+}
+
+func TestThinArrowToken(t *testing.T) {
+	testTokens(t, "fn(a: int) -> int { a -1 }", []expectedToken{
+		{token.FUNCTION, "fn"},
+		{token.LPAREN, "("},
+		{token.IDENT, "a"},
+		{token.COLON, ":"},
+		{token.IDENT, "int"},
+		{token.RPAREN, ")"},
+		{token.THIN_ARROW, "->"},
+		{token.IDENT, "int"},
+		{token.LBRACE, "{"},
+		{token.IDENT, "a"},
+		{token.MINUS, "-"},
+		{token.INT, "1"},
+		{token.RBRACE, "}"},
+		{token.EOF, ""},
+	})
+}
+
+func TestPipeToken(t *testing.T) {
+	testTokens(t, "xs |> sum | x", []expectedToken{
+		{token.IDENT, "xs"},
+		{token.PIPE, "|>"},
+		{token.IDENT, "sum"},
+		{token.ILLEGAL, "|"},
+		{token.IDENT, "x"},
+		{token.EOF, ""},
+	})
+}
+
+func TestMemberAndStringTokens(t *testing.T) {
+	input := `"abc".upper();
+person.name;
+"foo bar"
+"say \"hi\"\n"
+"unterminated`
+
+	testTokens(t, input, []expectedToken{
+		{token.STRING, "abc"},
+		{token.DOT, "."},
+		{token.IDENT, "upper"},
+		{token.LPAREN, "("},
+		{token.RPAREN, ")"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "person"},
+		{token.DOT, "."},
+		{token.IDENT, "name"},
+		{token.SEMICOLON, ";"},
+		{token.STRING, "foo bar"},
+		{token.STRING, "say \"hi\"\n"},
+		{token.ILLEGAL, "\"unterminated"},
+		{token.EOF, ""},
+	})
+}
+
+func TestTemplateLiteralTokens(t *testing.T) {
+	input := "`Hello ${user.name}, you have ${n} items`;\n" +
+		"`${ {a: 1}[\"a\"] }`;\n" +
+		"`outer ${ `inner ${x}` } \\` \\${}`"
+
+	testTokens(t, input, []expectedToken{
+		{token.TEMPLATE_START, "`"},
+		{token.TEMPLATE_STRING, "Hello "},
+		{token.TEMPLATE_EXPR_START, "${"},
+		{token.IDENT, "user"},
+		{token.DOT, "."},
+		{token.IDENT, "name"},
+		{token.TEMPLATE_EXPR_END, "}"},
+		{token.TEMPLATE_STRING, ", you have "},
+		{token.TEMPLATE_EXPR_START, "${"},
+		{token.IDENT, "n"},
+		{token.TEMPLATE_EXPR_END, "}"},
+		{token.TEMPLATE_STRING, " items"},
+		{token.TEMPLATE_END, "`"},
+		{token.SEMICOLON, ";"},
+		{token.TEMPLATE_START, "`"},
+		{token.TEMPLATE_EXPR_START, "${"},
+		{token.LBRACE, "{"},
+		{token.IDENT, "a"},
+		{token.COLON, ":"},
+		{token.INT, "1"},
+		{token.RBRACE, "}"},
+		{token.LBRACKET, "["},
+		{token.STRING, "a"},
+		{token.RBRACKET, "]"},
+		{token.TEMPLATE_EXPR_END, "}"},
+		{token.TEMPLATE_END, "`"},
+		{token.SEMICOLON, ";"},
+		{token.TEMPLATE_START, "`"},
+		{token.TEMPLATE_STRING, "outer "},
+		{token.TEMPLATE_EXPR_START, "${"},
+		{token.TEMPLATE_START, "`"},
+		{token.TEMPLATE_STRING, "inner "},
+		{token.TEMPLATE_EXPR_START, "${"},
+		{token.IDENT, "x"},
+		{token.TEMPLATE_EXPR_END, "}"},
+		{token.TEMPLATE_END, "`"},
+		{token.TEMPLATE_EXPR_END, "}"},
+		{token.TEMPLATE_STRING, " ` ${}"},
+		{token.TEMPLATE_END, "`"},
+		{token.EOF, ""},
+	})
+}
+
+func TestTokenPositions(t *testing.T) {
+	input := "let x = 5;\n  `a ${x}`\n\tfoo"
+
+	tests := []struct {
+		expectedType   token.TokenType
+		expectedLine   int
+		expectedColumn int
+	}{
+		{token.LET, 1, 1},
+		{token.IDENT, 1, 5},
+		{token.ASSIGN, 1, 7},
+		{token.INT, 1, 9},
+		{token.SEMICOLON, 1, 10},
+		{token.TEMPLATE_START, 2, 3},
+		{token.TEMPLATE_STRING, 2, 4},
+		{token.TEMPLATE_EXPR_START, 2, 6},
+		{token.IDENT, 2, 8},
+		{token.TEMPLATE_EXPR_END, 2, 9},
+		{token.TEMPLATE_END, 2, 10},
+		{token.IDENT, 3, 2},
+		{token.EOF, 3, 5},
+	}
+
+	newLexer := NewLexer(input)
+
+	for i, tt := range tests {
+		tok := newLexer.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q", i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Line != tt.expectedLine || tok.Column != tt.expectedColumn {
+			t.Errorf("tests[%d] - position wrong. expected=%d:%d, got=%d:%d",
+				i, tt.expectedLine, tt.expectedColumn, tok.Line, tok.Column)
+		}
+	}
+}
+
+func TestUnterminatedTemplateErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let s = `abc", "1:9: unterminated template literal"},
+		{"let s = `abc ${x + 1", "1:14: unterminated ${ in template literal"},
+		{"`a ${ {b: 1}", "1:4: unterminated ${ in template literal"},
+		{"\n  `a ${ `b", "2:9: unterminated template literal"},
+	}
+
+	for _, tt := range tests {
+		newLexer := NewLexer(tt.input)
+
+		for tok := newLexer.NextToken(); tok.Type != token.EOF; tok = newLexer.NextToken() {
+		}
+
+		errors := newLexer.Errors()
+
+		if len(errors) != 1 {
+			t.Fatalf("expected 1 error for %q. got=%v", tt.input, errors)
+		}
+
+		if errors[0] != tt.expected {
+			t.Errorf("wrong error for %q. expected=%q, got=%q", tt.input, tt.expected, errors[0])
+		}
+	}
+}
+
+func TestRawStringTokens(t *testing.T) {
+	input := "let re = r\"\\d+\\.\\d+\";\n" +
+		"let sql = \"\"\"\n" +
+		"    SELECT *\n" +
+		"      FROM users\n" +
+		"\n" +
+		"    WHERE name = \"\\n\"\n" +
+		"    \"\"\";\n" +
+		"let empty = \"\";\n" +
+		"r\"multi\nline\" \"\"\"inline\"\"\" rest"
+
+	testTokens(t, input, []expectedToken{
+		{token.LET, "let"},
+		{token.IDENT, "re"},
+		{token.ASSIGN, "="},
+		{token.STRING, `\d+\.\d+`},
+		{token.SEMICOLON, ";"},
+		{token.LET, "let"},
+		{token.IDENT, "sql"},
+		{token.ASSIGN, "="},
+		{token.STRING, "SELECT *\n  FROM users\n\nWHERE name = \"\\n\""},
+		{token.SEMICOLON, ";"},
+		{token.LET, "let"},
+		{token.IDENT, "empty"},
+		{token.ASSIGN, "="},
+		{token.STRING, ""},
+		{token.SEMICOLON, ";"},
+		{token.STRING, "multi\nline"},
+		{token.STRING, "inline"},
+		{token.IDENT, "rest"},
+		{token.EOF, ""},
+	})
+}
+
+func TestRawStringLineTracking(t *testing.T) {
+	input := "\"\"\"\n  a\n  b\n  \"\"\" x\nr\"1\n2\n\" y"
+
+	newLexer := NewLexer(input)
+
+	tests := []struct {
+		expectedLiteral string
+		expectedLine    int
+		expectedColumn  int
+	}{
+		{"a\nb", 1, 1},
+		{"x", 4, 7},
+		{"1\n2\n", 5, 1},
+		{"y", 7, 3},
+	}
+
+	for i, tt := range tests {
+		tok := newLexer.NextToken()
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q", i, tt.expectedLiteral, tok.Literal)
+		}
+
+		if tok.Line != tt.expectedLine || tok.Column != tt.expectedColumn {
+			t.Errorf("tests[%d] - position wrong. expected=%d:%d, got=%d:%d",
+				i, tt.expectedLine, tt.expectedColumn, tok.Line, tok.Column)
+		}
+	}
+}
+
+func TestUnterminatedStringErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let s = "abc`, "1:9: unterminated string literal"},
+		{"let s = r\"abc\n", "1:9: unterminated raw string literal"},
+		{"\nlet s = \"\"\"abc\"\"", "2:9: unterminated string literal"},
+	}
+
+	for _, tt := range tests {
+		newLexer := NewLexer(tt.input)
+
+		for tok := newLexer.NextToken(); tok.Type != token.EOF; tok = newLexer.NextToken() {
+		}
+
+		errors := newLexer.Errors()
+
+		if len(errors) != 1 || errors[0] != tt.expected {
+			t.Errorf("wrong errors for %q. expected=%q, got=%q", tt.input, tt.expected, errors)
+		}
+	}
+}
+
+func TestModuleKeywords(t *testing.T) {
+	testTokens(t, `import "lib/strings" as str; export let x = 1;`, []expectedToken{
+		{token.IMPORT, "import"},
+		{token.STRING, "lib/strings"},
+		{token.AS, "as"},
+		{token.IDENT, "str"},
+		{token.SEMICOLON, ";"},
+		{token.EXPORT, "export"},
+		{token.LET, "let"},
+		{token.IDENT, "x"},
+		{token.ASSIGN, "="},
+		{token.INT, "1"},
+		{token.SEMICOLON, ";"},
+		{token.EOF, ""},
+	})
+}
+
+func TestExceptionKeywords(t *testing.T) {
+	testTokens(t, `try { throw "bad"; } catch (e) { e } finally { close() }`, []expectedToken{
+		{token.TRY, "try"},
+		{token.LBRACE, "{"},
+		{token.THROW, "throw"},
+		{token.STRING, "bad"},
+		{token.SEMICOLON, ";"},
+		{token.RBRACE, "}"},
+		{token.CATCH, "catch"},
+		{token.LPAREN, "("},
+		{token.IDENT, "e"},
+		{token.RPAREN, ")"},
+		{token.LBRACE, "{"},
+		{token.IDENT, "e"},
+		{token.RBRACE, "}"},
+		{token.FINALLY, "finally"},
+		{token.LBRACE, "{"},
+		{token.IDENT, "close"},
+		{token.LPAREN, "("},
+		{token.RPAREN, ")"},
+		{token.RBRACE, "}"},
+		{token.EOF, ""},
+	})
+}
+
+func TestDeferKeyword(t *testing.T) {
+	testTokens(t, "defer close(f);", []expectedToken{
+		{token.DEFER, "defer"},
+		{token.IDENT, "close"},
+		{token.LPAREN, "("},
+		{token.IDENT, "f"},
+		{token.RPAREN, ")"},
+		{token.SEMICOLON, ";"},
+		{token.EOF, ""},
+	})
+}
+
+func TestGeneratorTokens(t *testing.T) {
+	testTokens(t, "fn*() { yield 1; }", []expectedToken{
+		{token.FUNCTION, "fn"},
+		{token.ASTERISK, "*"},
+		{token.LPAREN, "("},
+		{token.RPAREN, ")"},
+		{token.LBRACE, "{"},
+		{token.YIELD, "yield"},
+		{token.INT, "1"},
+		{token.SEMICOLON, ";"},
+		{token.RBRACE, "}"},
+		{token.EOF, ""},
+	})
+}
+
+func TestConcurrencyKeywords(t *testing.T) {
+	testTokens(t, "spawn f(); select { case v = recv(ch) { } default { } }", []expectedToken{
+		{token.SPAWN, "spawn"},
+		{token.IDENT, "f"},
+		{token.LPAREN, "("},
+		{token.RPAREN, ")"},
+		{token.SEMICOLON, ";"},
+		{token.SELECT, "select"},
+		{token.LBRACE, "{"},
+		{token.CASE, "case"},
+		{token.IDENT, "v"},
+		{token.ASSIGN, "="},
+		{token.IDENT, "recv"},
+		{token.LPAREN, "("},
+		{token.IDENT, "ch"},
+		{token.RPAREN, ")"},
+		{token.LBRACE, "{"},
+		{token.RBRACE, "}"},
+		{token.DEFAULT, "default"},
+		{token.LBRACE, "{"},
+		{token.RBRACE, "}"},
+		{token.RBRACE, "}"},
+		{token.EOF, ""},
+	})
+}
+
+func TestStructKeyword(t *testing.T) {
+	testTokens(t, "struct Point { x, y }", []expectedToken{
+		{token.STRUCT, "struct"},
+		{token.IDENT, "Point"},
+		{token.LBRACE, "{"},
+		{token.IDENT, "x"},
+		{token.COMMA, ","},
+		{token.IDENT, "y"},
+		{token.RBRACE, "}"},
+		{token.EOF, ""},
+	})
+}
+
+func TestEnumAndMatchKeywords(t *testing.T) {
+	testTokens(t, "enum S { A } match s { A => 1 }", []expectedToken{
+		{token.ENUM, "enum"},
+		{token.IDENT, "S"},
+		{token.LBRACE, "{"},
+		{token.IDENT, "A"},
+		{token.RBRACE, "}"},
+		{token.MATCH, "match"},
+		{token.IDENT, "s"},
+		{token.LBRACE, "{"},
+		{token.IDENT, "A"},
+		{token.ARROW, "=>"},
+		{token.INT, "1"},
+		{token.RBRACE, "}"},
+		{token.EOF, ""},
+	})
+}
+
+func TestComments(t *testing.T) {
+	input := "// header\nlet x = 5; // five  \n\n  // indented\n// twice\nx / 2 //"
+
+	testTokens(t, input, []expectedToken{
+		{token.LET, "let"},
+		{token.IDENT, "x"},
+		{token.ASSIGN, "="},
+		{token.INT, "5"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "x"},
+		{token.SLASH, "/"},
+		{token.INT, "2"},
+		{token.EOF, ""},
+	})
+
+	newLexer := NewLexer(input)
+	for tok := newLexer.NextToken(); tok.Type != token.EOF; tok = newLexer.NextToken() {
+	}
+
+	expected := []token.Token{
+		{Type: token.COMMENT, Literal: "// header", Line: 1, Column: 1},
+		{Type: token.COMMENT, Literal: "// five", Line: 2, Column: 12},
+		{Type: token.COMMENT, Literal: "// indented", Line: 4, Column: 3},
+		{Type: token.COMMENT, Literal: "// twice", Line: 5, Column: 1},
+		{Type: token.COMMENT, Literal: "//", Line: 6, Column: 7},
+	}
+
+	comments := newLexer.Comments()
+
+	if len(comments) != len(expected) {
+		t.Fatalf("wrong number of comments. expected=%d, got=%d (%v)", len(expected), len(comments), comments)
+	}
+
+	for i, comment := range comments {
+		if comment != expected[i] {
+			t.Errorf("comments[%d] wrong. expected=%+v, got=%+v", i, expected[i], comment)
+		}
+	}
+}
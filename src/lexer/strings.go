@@ -0,0 +1,90 @@
+package lexer
+
+import "strings"
+
+// readRawString reads r"..." starting at the opening quote. Backslashes
+// and newlines are kept verbatim.
+func (lexer *Lexer) readRawString() (string, bool) {
+	position := lexer.position + 1
+
+	for {
+		lexer.readChar()
+
+		switch lexer.currentChar {
+		case '"':
+			return lexer.input[position:lexer.position], true
+		case 0:
+			return lexer.input[position:lexer.position], false
+		}
+	}
+}
+
+// readTripleQuotedString reads a raw """...""" string starting at the first
+// opening quote and strips the indentation common to all of its lines.
+func (lexer *Lexer) readTripleQuotedString() (string, bool) {
+	lexer.readChar()
+	lexer.readChar()
+
+	position := lexer.position + 1
+
+	for {
+		lexer.readChar()
+
+		if lexer.currentChar == 0 {
+			return lexer.input[position:lexer.position], false
+		}
+
+		if lexer.currentChar == '"' && lexer.peekChar() == '"' && lexer.peekCharAt(2) == '"' {
+			literal := lexer.input[position:lexer.position]
+
+			lexer.readChar()
+			lexer.readChar()
+
+			return trimIndent(literal), true
+		}
+	}
+}
+
+// trimIndent drops a leading newline and a trailing blank line, then
+// removes the longest whitespace prefix shared by all non-blank lines.
+func trimIndent(literal string) string {
+	literal = strings.TrimPrefix(literal, "\r")
+	literal = strings.TrimPrefix(literal, "\n")
+	lines := strings.Split(literal, "\n")
+
+	if len(lines) > 1 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	indent := ""
+	first := true
+
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		prefix := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+
+		if first {
+			indent = prefix
+			first = false
+
+			continue
+		}
+
+		for !strings.HasPrefix(prefix, indent) {
+			indent = indent[:len(indent)-1]
+		}
+	}
+
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			lines[i] = ""
+		} else {
+			lines[i] = strings.TrimPrefix(line, indent)
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
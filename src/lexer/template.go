@@ -0,0 +1,75 @@
+package lexer
+
+import "monkey/token"
+
+// templateState tracks one open template literal. While inText is set the
+// lexer reads literal text; otherwise it is inside an embedded ${...}
+// expression and braces counts the unmatched { seen there.
+type templateState struct {
+	inText bool
+	braces int
+
+	line, column         int // position of the opening backtick
+	exprLine, exprColumn int // position of the current ${
+}
+
+func (lexer *Lexer) currentTemplate() *templateState {
+	if len(lexer.templates) == 0 {
+		return nil
+	}
+
+	return lexer.templates[len(lexer.templates)-1]
+}
+
+// readTemplateText reads the next token inside the text part of a template
+// literal: a run of text, the ${ opening an embedded expression, or the
+// closing backtick.
+func (lexer *Lexer) readTemplateText(template *templateState) token.Token {
+	tok := token.Token{Line: lexer.line, Column: lexer.column}
+
+	switch {
+	case lexer.currentChar == '`':
+		lexer.templates = lexer.templates[:len(lexer.templates)-1]
+		tok.Type, tok.Literal = token.TEMPLATE_END, "`"
+		lexer.readChar()
+
+	case lexer.currentChar == '$' && lexer.peekChar() == '{':
+		template.inText = false
+		template.braces = 0
+		template.exprLine, template.exprColumn = lexer.line, lexer.column
+		tok.Type, tok.Literal = token.TEMPLATE_EXPR_START, "${"
+		lexer.readChar()
+		lexer.readChar()
+
+	case lexer.currentChar == 0:
+		lexer.errorf(template.line, template.column, "unterminated template literal")
+		lexer.templates = nil
+		tok.Type, tok.Literal = token.ILLEGAL, ""
+
+	default:
+		tok.Type, tok.Literal = token.TEMPLATE_STRING, lexer.readTemplateString()
+	}
+
+	return tok
+}
+
+func (lexer *Lexer) readTemplateString() string {
+	var out []byte
+
+	for lexer.currentChar != 0 && lexer.currentChar != '`' {
+		if lexer.currentChar == '$' && lexer.peekChar() == '{' {
+			break
+		}
+
+		if lexer.currentChar == '\\' && lexer.peekChar() != 0 {
+			lexer.readChar()
+			out = append(out, unescape(lexer.currentChar))
+		} else {
+			out = append(out, lexer.currentChar)
+		}
+
+		lexer.readChar()
+	}
+
+	return string(out)
+}
@@ -0,0 +1,83 @@
+package lint
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ConfigFile is the name of the file FindConfig looks for.
+const ConfigFile = ".monkeylint.json"
+
+// Config selects the rules Lint runs. In a config file it reads
+//
+//	{
+//	    "rules": {"shadow": false, "unused-parameter": true},
+//	    "builtins": ["len", "puts"]
+//	}
+type Config struct {
+	Rules    map[string]bool `json:"rules"`    // rules not listed are enabled
+	Builtins []string        `json:"builtins"` // names the host predeclares
+}
+
+// Enabled reports whether config runs rule.
+func (config Config) Enabled(rule *Rule) bool {
+	if enabled, ok := config.Rules[rule.Name]; ok {
+		return enabled
+	}
+
+	return true
+}
+
+// LoadConfig reads a config file, rejecting unknown fields and rules.
+func LoadConfig(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+
+	if err != nil {
+		return Config{}, err
+	}
+
+	config := Config{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(&config); err != nil {
+		return Config{}, fmt.Errorf("%s: %v", path, err)
+	}
+
+	for name := range config.Rules {
+		if Lookup(name) == nil {
+			return Config{}, fmt.Errorf("%s: unknown rule %q", path, name)
+		}
+	}
+
+	return config, nil
+}
+
+// FindConfig looks for ConfigFile in dir and its parent directories and
+// returns the path of the first one found, or "" if there is none.
+func FindConfig(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		path := filepath.Join(dir, ConfigFile)
+
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path, nil
+		}
+
+		parent := filepath.Dir(dir)
+
+		if parent == dir {
+			return "", nil
+		}
+
+		dir = parent
+	}
+}
@@ -0,0 +1,196 @@
+// Package lint runs static checks over Monkey programs.
+//
+// Every check is a Rule. Which rules run is chosen by a Config, usually
+// read from a .monkeylint.json file, and single findings are silenced with
+// comments:
+//
+//	// lint:ignore unused-variable
+//	let scratch = 1;
+//
+// A lint:ignore comment applies to its own line and the line after it, a
+// lint:file-ignore comment to the whole file. Without rule names they
+// silence every rule.
+package lint
+
+import (
+	"fmt"
+	"monkey/ast"
+	"monkey/lexer"
+	"monkey/parser"
+	"monkey/resolver"
+	"monkey/token"
+	"sort"
+	"strings"
+)
+
+// Finding is a problem reported by a rule at the position of a token.
+type Finding struct {
+	Rule    string `json:"rule"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Message string `json:"message"`
+}
+
+func (finding Finding) String() string {
+	return fmt.Sprintf("%d:%d: %s (%s)", finding.Line, finding.Column, finding.Message, finding.Rule)
+}
+
+type Rule struct {
+	Name string
+	Doc  string // one line describing what the rule reports
+
+	check func(pass *pass)
+}
+
+// Rules lists every rule in the order their findings are reported for the
+// same position.
+var Rules = []*Rule{
+	resolution,
+	unusedVariable,
+	unusedParameter,
+	shadow,
+	unreachable,
+	constantCondition,
+	selfComparison,
+	bangPrecedence,
+}
+
+// Lookup returns the rule called name, or nil.
+func Lookup(name string) *Rule {
+	for _, rule := range Rules {
+		if rule.Name == name {
+			return rule
+		}
+	}
+
+	return nil
+}
+
+// pass is the state shared by the rules while linting one program.
+type pass struct {
+	program    *ast.Program
+	resolution *resolver.Resolution
+	exported   map[*ast.Identifier]bool
+
+	rule     *Rule
+	findings []Finding
+}
+
+func (pass *pass) report(tok token.Token, format string, args ...interface{}) {
+	pass.findings = append(pass.findings, Finding{
+		Rule:    pass.rule.Name,
+		Line:    tok.Line,
+		Column:  tok.Column,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// Lint runs the rules enabled by config over program and returns their
+// findings in source order, leaving out those silenced by comments.
+func Lint(program *ast.Program, comments []token.Token, config Config) []Finding {
+	pass := &pass{
+		program:    program,
+		resolution: resolver.Resolve(program, config.Builtins...),
+		exported:   map[*ast.Identifier]bool{},
+		findings:   []Finding{},
+	}
+
+	for _, statement := range program.Statemens {
+		if export, ok := statement.(*ast.ExportStatement); ok {
+			for _, name := range ast.PatternNames(export.Declaration.Target()) {
+				pass.exported[name] = true
+			}
+		}
+	}
+
+	for _, rule := range Rules {
+		if config.Enabled(rule) {
+			pass.rule = rule
+			rule.check(pass)
+		}
+	}
+
+	suppressions := parseSuppressions(comments)
+	findings := []Finding{}
+
+	for _, finding := range pass.findings {
+		if !suppressions.silences(finding) {
+			findings = append(findings, finding)
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Line != findings[j].Line {
+			return findings[i].Line < findings[j].Line
+		}
+
+		return findings[i].Column < findings[j].Column
+	})
+
+	return findings
+}
+
+// Source parses source and lints it. It returns an error listing the
+// parser errors if source does not parse.
+func Source(source []byte, config Config) ([]Finding, error) {
+	newLexer := lexer.NewLexer(string(source))
+	newParser := parser.NewParser(newLexer)
+	program := newParser.ParseProgram()
+
+	if errors := newParser.Errors(); len(errors) > 0 {
+		return nil, fmt.Errorf("%s", strings.Join(errors, "\n"))
+	}
+
+	return Lint(program, newLexer.Comments(), config), nil
+}
+
+// suppressions records the rules silenced per line and for the whole file.
+// An empty rule name stands for every rule.
+type suppressions struct {
+	lines map[int]map[string]bool
+	file  map[string]bool
+}
+
+func parseSuppressions(comments []token.Token) suppressions {
+	result := suppressions{lines: map[int]map[string]bool{}, file: map[string]bool{}}
+
+	for _, comment := range comments {
+		fields := strings.Fields(strings.TrimPrefix(comment.Literal, "//"))
+
+		if len(fields) == 0 {
+			continue
+		}
+
+		rules := fields[1:]
+
+		if len(rules) == 0 {
+			rules = []string{""}
+		}
+
+		switch fields[0] {
+		case "lint:ignore":
+			for _, line := range []int{comment.Line, comment.Line + 1} {
+				if result.lines[line] == nil {
+					result.lines[line] = map[string]bool{}
+				}
+
+				for _, rule := range rules {
+					result.lines[line][rule] = true
+				}
+			}
+
+		case "lint:file-ignore":
+			for _, rule := range rules {
+				result.file[rule] = true
+			}
+		}
+	}
+
+	return result
+}
+
+func (suppressions suppressions) silences(finding Finding) bool {
+	line := suppressions.lines[finding.Line]
+
+	return suppressions.file[""] || suppressions.file[finding.Rule] || line[""] || line[finding.Rule]
+}
@@ -0,0 +1,159 @@
+package lint
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func lint(t *testing.T, input string, config Config) []string {
+	t.Helper()
+
+	findings, err := Source([]byte(input), config)
+
+	if err != nil {
+		t.Fatalf("Source(%q) failed: %v", input, err)
+	}
+
+	result := []string{}
+	for _, finding := range findings {
+		result = append(result, finding.String())
+	}
+
+	return result
+}
+
+func TestRules(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"let x = 1;", []string{"1:5: variable x is never used (unused-variable)"}},
+		{"let f = fn(a) { let a = 1; a }; f(y);", []string{
+			"1:21: a is already declared at 1:12 (resolution)",
+			"1:35: y is not defined (resolution)",
+		}},
+		{"let _x = 1; export let y = 2; let [a, b] = y; a;", []string{"1:39: variable b is never used (unused-variable)"}},
+		{"try { len() } catch (err) { 0 }", []string{"1:22: catch parameter err is never used (unused-variable)"}},
+		{"let f = fn(a, _b, c) { a }; f;", []string{"1:19: parameter c is never used (unused-parameter)"}},
+		{"let x = 1; let f = fn(x) { x }; f(x);", []string{"1:23: x shadows the variable declared at 1:5 (shadow)"}},
+		{"let f = fn(len) { len }; f;", []string{"1:12: len shadows a builtin (shadow)"}},
+		{"let f = fn() { return 1; f(); 2 }; f;", []string{"1:26: unreachable code after return (unreachable)"}},
+		{"let f = fn(e) { throw e; }; f;", []string{}},
+		{"if (true) { 1 }", []string{"1:1: if condition is always true (constant-condition)"}},
+		{"if (1 < 2) { 1 } else if (3) { 2 }", []string{
+			"1:1: if condition is constant (constant-condition)",
+			"1:23: if condition is constant (constant-condition)",
+		}},
+		{"let x = 1; if (x) { 1 }", []string{}},
+		{"let x = 1; x == x; x.a[0] != x.a[0];", []string{
+			"1:14: comparison of x with itself is always true (self-comparison)",
+			"1:27: comparison of (x.a[0]) with itself is always false (self-comparison)",
+		}},
+		{"let f = fn() { 1 }; f() == f(); [1] == [1];", []string{}},
+		{"let a = 1; let b = 2; !a == b; !(a == b); a == !b;", []string{
+			"1:23: !a == b is (!a) == b; write !(a == b) to negate the comparison (bang-precedence)",
+		}},
+	}
+
+	for _, tt := range tests {
+		got := lint(t, tt.input, Config{Builtins: []string{"len"}})
+
+		if strings.Join(got, "\n") != strings.Join(tt.expected, "\n") {
+			t.Errorf("wrong findings for %q.\nexpected=%q\ngot=     %q", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestConfigDisablesRules(t *testing.T) {
+	input := "let x = 1; x == x;"
+
+	got := lint(t, input, Config{Rules: map[string]bool{"self-comparison": false}})
+
+	if fmt.Sprint(got) != "[]" {
+		t.Errorf("expected no findings with self-comparison disabled. got=%q", got)
+	}
+
+	got = lint(t, input, Config{Rules: map[string]bool{"unused-variable": true}})
+
+	if len(got) != 1 || !strings.HasSuffix(got[0], "(self-comparison)") {
+		t.Errorf("expected rules not listed to stay enabled. got=%q", got)
+	}
+}
+
+func TestSuppressionComments(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int
+	}{
+		{"let x = 1; // lint:ignore unused-variable", 0},
+		{"// lint:ignore unused-variable\nlet x = 1;", 0},
+		{"// lint:ignore\nlet x = 1;", 0},
+		{"// lint:ignore shadow\nlet x = 1;", 1},
+		{"// lint:ignore unused-variable\n\nlet x = 1;", 1},
+		{"// lint:file-ignore unused-variable\n\nlet x = 1;\nlet y = 2;", 0},
+		{"// lint:file-ignore\nlet x = 1; x == x;", 0},
+		{"// just a comment\nlet x = 1;", 1},
+	}
+
+	for _, tt := range tests {
+		if got := lint(t, tt.input, Config{}); len(got) != tt.expected {
+			t.Errorf("wrong findings for %q. expected %d, got=%q", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	root := t.TempDir()
+	nested := filepath.Join(root, "scripts", "deep")
+
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(root, ConfigFile)
+	content := `{"rules": {"shadow": false}, "builtins": ["puts"]}`
+
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	found, err := FindConfig(nested)
+
+	if err != nil || found != path {
+		t.Fatalf("FindConfig(%q) = %q, %v. expected %q", nested, found, err, path)
+	}
+
+	config, err := LoadConfig(found)
+
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+
+	if config.Enabled(Lookup("shadow")) || !config.Enabled(Lookup("unreachable")) {
+		t.Errorf("wrong rules enabled. got=%v", config.Rules)
+	}
+
+	if fmt.Sprint(config.Builtins) != "[puts]" {
+		t.Errorf("wrong builtins. got=%v", config.Builtins)
+	}
+
+	for content, expected := range map[string]string{
+		`{"rules": {"nope": true}}`: `unknown rule "nope"`,
+		`{"rulez": {}}`:             `unknown field "rulez"`,
+	} {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := LoadConfig(path); err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("wrong error for %s. expected to contain %q, got=%v", content, expected, err)
+		}
+	}
+
+	if found, err := FindConfig(t.TempDir()); err != nil || found != "" {
+		t.Errorf("expected no config to be found. got=%q, %v", found, err)
+	}
+}
@@ -0,0 +1,235 @@
+package lint
+
+import (
+	"monkey/ast"
+	"monkey/resolver"
+	"monkey/token"
+	"reflect"
+	"strings"
+)
+
+var resolution = &Rule{
+	Name: "resolution",
+	Doc:  "names that are not defined, declared twice in a scope, used before their declaration, or _ used as a value",
+	check: func(pass *pass) {
+		for _, err := range pass.resolution.Errors {
+			pass.report(token.Token{Line: err.Line, Column: err.Column}, "%s", err.Message)
+		}
+	},
+}
+
+var unusedVariable = &Rule{
+	Name: "unused-variable",
+	Doc:  "variables, constants and catch parameters that are never used, unless exported or named _something",
+	check: func(pass *pass) {
+		pass.unused(func(declaration *resolver.Declaration) bool {
+			switch declaration.Kind {
+			case resolver.Variable, resolver.Constant:
+				return !pass.exported[declaration.Name]
+			case resolver.Catch:
+				return true
+			}
+
+			return false
+		})
+	},
+}
+
+var unusedParameter = &Rule{
+	Name: "unused-parameter",
+	Doc:  "function parameters that are never used, unless named _something",
+	check: func(pass *pass) {
+		pass.unused(func(declaration *resolver.Declaration) bool {
+			return declaration.Kind == resolver.Parameter
+		})
+	},
+}
+
+// unused reports the included declarations without uses. A duplicate
+// declaration is left to the resolution rule, as its name refers to the
+// first one.
+func (pass *pass) unused(include func(*resolver.Declaration) bool) {
+	pass.declarations(pass.resolution.Program, func(declaration *resolver.Declaration) {
+		name := declaration.Name
+		duplicate := declaration.Scope.Local(name.Value) != declaration
+
+		if include(declaration) && !duplicate && len(declaration.Uses) == 0 && !strings.HasPrefix(name.Value, "_") {
+			pass.report(name.Token, "%s %s is never used", declaration.Kind, name.Value)
+		}
+	})
+}
+
+// declarations calls f for the declarations of scope and the scopes nested
+// in it, in source order per scope.
+func (pass *pass) declarations(scope *resolver.Scope, f func(*resolver.Declaration)) {
+	for _, declaration := range scope.Declarations {
+		f(declaration)
+	}
+
+	for _, child := range scope.Children {
+		pass.declarations(child, f)
+	}
+}
+
+var shadow = &Rule{
+	Name: "shadow",
+	Doc:  "declarations that hide a declaration of the same name in an enclosing scope",
+	check: func(pass *pass) {
+		pass.declarations(pass.resolution.Program, func(declaration *resolver.Declaration) {
+			shadowed := declaration.Shadows
+
+			switch {
+			case shadowed == nil:
+			case shadowed.Kind == resolver.Builtin:
+				pass.report(declaration.Name.Token, "%s shadows a builtin", declaration.Name.Value)
+			default:
+				pass.report(declaration.Name.Token, "%s shadows the %s declared at %d:%d",
+					declaration.Name.Value, shadowed.Kind, shadowed.Name.Token.Line, shadowed.Name.Token.Column)
+			}
+		})
+	},
+}
+
+var unreachable = &Rule{
+	Name: "unreachable",
+	Doc:  "statements following a return or throw in the same block",
+	check: func(pass *pass) {
+		ast.Inspect(pass.program, func(node ast.Node) bool {
+			var statements []ast.Statement
+
+			switch node := node.(type) {
+			case *ast.Program:
+				statements = node.Statemens
+			case *ast.BlockStatement:
+				statements = node.Statements
+			}
+
+			for i, statement := range statements[:max(len(statements)-1, 0)] {
+				var keyword string
+
+				switch statement.(type) {
+				case *ast.ReturnStatement:
+					keyword = "return"
+				case *ast.ThrowStatement:
+					keyword = "throw"
+				default:
+					continue
+				}
+
+				pass.report(statementToken(statements[i+1]), "unreachable code after %s", keyword)
+
+				break
+			}
+
+			return true
+		})
+	},
+}
+
+// statementToken returns the token every statement type stores first.
+func statementToken(statement ast.Statement) token.Token {
+	return reflect.ValueOf(statement).Elem().FieldByName("Token").Interface().(token.Token)
+}
+
+var constantCondition = &Rule{
+	Name: "constant-condition",
+	Doc:  "if conditions made only of literals",
+	check: func(pass *pass) {
+		ast.Inspect(pass.program, func(node ast.Node) bool {
+			if expression, ok := node.(*ast.IfExpression); ok && constant(expression.Condition) {
+				if boolean, ok := expression.Condition.(*ast.Boolean); ok {
+					pass.report(expression.Token, "if condition is always %t", boolean.Value)
+				} else {
+					pass.report(expression.Token, "if condition is constant")
+				}
+			}
+
+			return true
+		})
+	},
+}
+
+func constant(expression ast.Expression) bool {
+	switch expression := expression.(type) {
+	case *ast.IntegerLiteral, *ast.Boolean, *ast.StringLiteral:
+		return true
+	case *ast.PrefixExpression:
+		return constant(expression.Right)
+	case *ast.InfixExpression:
+		return constant(expression.Left) && constant(expression.Right)
+	default:
+		return false
+	}
+}
+
+var comparisonResults = map[string]bool{"==": true, "!=": false, "<": false, ">": false}
+
+var selfComparison = &Rule{
+	Name: "self-comparison",
+	Doc:  "comparisons of an expression with itself, such as x == x",
+	check: func(pass *pass) {
+		ast.Inspect(pass.program, func(node ast.Node) bool {
+			expression, ok := node.(*ast.InfixExpression)
+
+			if !ok {
+				return true
+			}
+
+			result, comparison := comparisonResults[expression.Operator]
+
+			if comparison && repeatable(expression.Left) &&
+				ast.Equal(expression.Left, expression.Right, ast.EqualOptions{IgnorePositions: true}) {
+				pass.report(expression.Token, "comparison of %s with itself is always %t", expression.Left.String(), result)
+			}
+
+			return true
+		})
+	},
+}
+
+// repeatable reports whether evaluating expression twice gives the same
+// value: it reads names, fields and elements, but calls nothing and
+// creates no new arrays, hashes or functions.
+func repeatable(expression ast.Expression) bool {
+	result := true
+
+	ast.Inspect(expression, func(node ast.Node) bool {
+		switch node.(type) {
+		case nil, *ast.Identifier, *ast.IntegerLiteral, *ast.Boolean, *ast.StringLiteral,
+			*ast.PrefixExpression, *ast.InfixExpression, *ast.MemberExpression, *ast.IndexExpression:
+		default:
+			result = false
+		}
+
+		return result
+	})
+
+	return result
+}
+
+var bangPrecedence = &Rule{
+	Name: "bang-precedence",
+	Doc:  "! on the left of a comparison, which negates the operand and not the comparison",
+	check: func(pass *pass) {
+		ast.Inspect(pass.program, func(node ast.Node) bool {
+			expression, ok := node.(*ast.InfixExpression)
+
+			if !ok {
+				return true
+			}
+
+			if _, comparison := comparisonResults[expression.Operator]; !comparison {
+				return true
+			}
+
+			if bang, ok := expression.Left.(*ast.PrefixExpression); ok && bang.Operator == "!" {
+				operand, operator, right := bang.Right.String(), expression.Operator, expression.Right.String()
+
+				pass.report(bang.Token, "!%s %s %s is (!%s) %s %s; write !(%s %s %s) to negate the comparison",
+					operand, operator, right, operand, operator, right, operand, operator, right)
+			}
+
+			return true
+		})
+	},
+}
@@ -8,6 +8,12 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		if command, ok := commands[os.Args[1]]; ok {
+			os.Exit(command(os.Args[2:]))
+		}
+	}
+
 	user, err := user.Current()
 
 	if err != nil {
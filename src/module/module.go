@@ -0,0 +1,182 @@
+package module
+
+import (
+	"fmt"
+	"monkey/ast"
+	"monkey/lexer"
+	"monkey/parser"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Extension is appended to import paths that do not name a file extension.
+const Extension = ".monkey"
+
+type Module struct {
+	Path    string // absolute path of the source file
+	Program *ast.Program
+	Imports []*Module // in the order of the import statements
+	Exports []string  // names bound by export declarations
+}
+
+// Loader resolves, parses and caches modules. Every module is parsed at
+// most once per loader, no matter how many modules import it.
+type Loader struct {
+	SearchPath []string
+
+	modules map[string]*Module
+	loading []string // modules currently being loaded, outermost first
+}
+
+/* Loader constructor */
+func NewLoader(searchPath ...string) *Loader {
+	return &Loader{SearchPath: searchPath, modules: map[string]*Module{}}
+}
+
+// Load loads the module stored in the file at path together with
+// everything it imports.
+func (loader *Loader) Load(path string) (*Module, error) {
+	absolute, err := filepath.Abs(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return loader.load(absolute)
+}
+
+// Import loads the module that an import statement in the file from refers
+// to by name.
+func (loader *Loader) Import(name, from string) (*Module, error) {
+	path, err := loader.Resolve(name, from)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return loader.load(path)
+}
+
+// Resolve finds the file an import name refers to. Names starting with ./
+// or ../ are relative to the importing file only; any other name is looked
+// up next to the importing file first and then in each search path entry.
+func (loader *Loader) Resolve(name, from string) (string, error) {
+	file := filepath.FromSlash(name)
+
+	if filepath.Ext(file) == "" {
+		file += Extension
+	}
+
+	if filepath.IsAbs(file) {
+		if err := checkFile(file, name); err != nil {
+			return "", err
+		}
+
+		return file, nil
+	}
+
+	directories := []string{filepath.Dir(from)}
+
+	if !strings.HasPrefix(name, "./") && !strings.HasPrefix(name, "../") {
+		directories = append(directories, loader.SearchPath...)
+	}
+
+	for _, directory := range directories {
+		candidate, err := filepath.Abs(filepath.Join(directory, file))
+
+		if err != nil {
+			return "", err
+		}
+
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate, nil
+		}
+	}
+
+	return "", fmt.Errorf("cannot find module %q imported from %s (searched %s)",
+		name, from, strings.Join(directories, ", "))
+}
+
+func (loader *Loader) load(path string) (*Module, error) {
+	if module, ok := loader.modules[path]; ok {
+		return module, nil
+	}
+
+	for i, loading := range loader.loading {
+		if loading == path {
+			return nil, loader.cycleError(loader.loading[i:], path)
+		}
+	}
+
+	loader.loading = append(loader.loading, path)
+	defer func() { loader.loading = loader.loading[:len(loader.loading)-1] }()
+
+	source, err := os.ReadFile(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	newParser := parser.NewParser(lexer.NewLexer(string(source)))
+	program := newParser.ParseProgram()
+
+	if errors := newParser.Errors(); len(errors) > 0 {
+		return nil, fmt.Errorf("%s: %s", path, strings.Join(errors, "\n"+path+": "))
+	}
+
+	module := &Module{Path: path, Program: program, Imports: []*Module{}}
+
+	for _, statement := range program.Statemens {
+		switch statement := statement.(type) {
+		case *ast.ImportStatement:
+			imported, err := loader.Import(statement.Path.Value, path)
+
+			if err != nil {
+				return nil, err
+			}
+
+			module.Imports = append(module.Imports, imported)
+
+		case *ast.ExportStatement:
+			for _, name := range ast.PatternNames(statement.Declaration.Target()) {
+				for _, exported := range module.Exports {
+					if exported == name.Value {
+						return nil, fmt.Errorf("%s: duplicate export %s", path, name.Value)
+					}
+				}
+
+				module.Exports = append(module.Exports, name.Value)
+			}
+		}
+	}
+
+	loader.modules[path] = module
+
+	return module, nil
+}
+
+// cycleError describes an import cycle as a readable chain of files, such as
+// "import cycle: a.monkey -> b.monkey -> a.monkey".
+func (loader *Loader) cycleError(chain []string, path string) error {
+	base := filepath.Dir(loader.loading[0])
+	names := []string{}
+
+	for _, file := range append(append([]string{}, chain...), path) {
+		if relative, err := filepath.Rel(base, file); err == nil {
+			file = relative
+		}
+
+		names = append(names, filepath.ToSlash(file))
+	}
+
+	return fmt.Errorf("import cycle: %s", strings.Join(names, " -> "))
+}
+
+func checkFile(path, name string) error {
+	if info, err := os.Stat(path); err != nil || info.IsDir() {
+		return fmt.Errorf("cannot find module %q", name)
+	}
+
+	return nil
+}
@@ -0,0 +1,170 @@
+package module
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	root := t.TempDir()
+
+	for name, source := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte(source), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return root
+}
+
+func TestLoadResolvesImports(t *testing.T) {
+	root := writeFiles(t, map[string]string{
+		"app/main.monkey":           `import "lib/strings" as str; import "./helpers" as h; str.upper(h.name);`,
+		"app/helpers.monkey":        `import "lib/strings" as str; export let name = "monkey";`,
+		"shared/lib/strings.monkey": `export let upper = fn(s) { s }; export let [lower, trim] = [1, 2];`,
+	})
+
+	loader := NewLoader(filepath.Join(root, "shared"))
+	module, err := loader.Load(filepath.Join(root, "app", "main.monkey"))
+
+	if err != nil {
+		t.Fatalf("Load returned error: %s", err)
+	}
+
+	if len(module.Imports) != 2 {
+		t.Fatalf("module.Imports has not 2 modules. got=%d", len(module.Imports))
+	}
+
+	library, helpers := module.Imports[0], module.Imports[1]
+
+	if library.Path != filepath.Join(root, "shared", "lib", "strings.monkey") {
+		t.Errorf("strings resolved to wrong path. got=%s", library.Path)
+	}
+
+	if helpers.Path != filepath.Join(root, "app", "helpers.monkey") {
+		t.Errorf("helpers resolved to wrong path. got=%s", helpers.Path)
+	}
+
+	if helpers.Imports[0] != library {
+		t.Errorf("lib/strings was loaded twice instead of being cached")
+	}
+
+	expected := []string{"upper", "lower", "trim"}
+
+	if len(library.Exports) != len(expected) {
+		t.Fatalf("wrong exports. expected=%v, got=%v", expected, library.Exports)
+	}
+
+	for i, name := range expected {
+		if library.Exports[i] != name {
+			t.Errorf("exports[%d] wrong. expected=%s, got=%s", i, name, library.Exports[i])
+		}
+	}
+}
+
+func TestResolvePrefersImportingDirectory(t *testing.T) {
+	root := writeFiles(t, map[string]string{
+		"app/main.monkey":     `import "util";`,
+		"app/util.monkey":     `1;`,
+		"lib/util.monkey":     `2;`,
+		"lib/only.monkey":     `3;`,
+		"app/sub/deep.monkey": `4;`,
+	})
+
+	loader := NewLoader(filepath.Join(root, "lib"))
+	from := filepath.Join(root, "app", "main.monkey")
+
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"util", filepath.Join(root, "app", "util.monkey")},
+		{"only", filepath.Join(root, "lib", "only.monkey")},
+		{"sub/deep", filepath.Join(root, "app", "sub", "deep.monkey")},
+		{"./util.monkey", filepath.Join(root, "app", "util.monkey")},
+		{"../lib/util", filepath.Join(root, "lib", "util.monkey")},
+	}
+
+	for _, tt := range tests {
+		path, err := loader.Resolve(tt.name, from)
+
+		if err != nil {
+			t.Errorf("Resolve(%q) returned error: %s", tt.name, err)
+			continue
+		}
+
+		if path != tt.expected {
+			t.Errorf("Resolve(%q) wrong. expected=%s, got=%s", tt.name, tt.expected, path)
+		}
+	}
+
+	if _, err := loader.Resolve("./only", from); err == nil {
+		t.Errorf("expected ./only not to be looked up in the search path")
+	}
+}
+
+func TestImportErrors(t *testing.T) {
+	tests := []struct {
+		files    map[string]string
+		expected string
+	}{
+		{
+			map[string]string{
+				"a.monkey": `import "b";`,
+				"b.monkey": `import "c";`,
+				"c.monkey": `import "a";`,
+			},
+			"import cycle: a.monkey -> b.monkey -> c.monkey -> a.monkey",
+		},
+		{
+			map[string]string{
+				"a.monkey": `import "a";`,
+			},
+			"import cycle: a.monkey -> a.monkey",
+		},
+		{
+			map[string]string{
+				"a.monkey": `import "missing";`,
+			},
+			`cannot find module "missing"`,
+		},
+		{
+			map[string]string{
+				"a.monkey": `export let x = 1; export let [x] = [2];`,
+			},
+			"duplicate export x",
+		},
+		{
+			map[string]string{
+				"a.monkey": `let f = fn() { import "b"; };`,
+				"b.monkey": `1;`,
+			},
+			"import is only allowed at the top level of a module",
+		},
+	}
+
+	for _, tt := range tests {
+		root := writeFiles(t, tt.files)
+
+		_, err := NewLoader().Load(filepath.Join(root, "a.monkey"))
+
+		if err == nil {
+			t.Errorf("expected error containing %q, got none", tt.expected)
+			continue
+		}
+
+		if !strings.Contains(err.Error(), tt.expected) {
+			t.Errorf("wrong error. expected to contain %q, got=%q", tt.expected, err.Error())
+		}
+	}
+}
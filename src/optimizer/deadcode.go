@@ -0,0 +1,110 @@
+package optimizer
+
+import "monkey/ast"
+
+// EliminateDeadBranches replaces if expressions whose condition is true or
+// false by the branch that is taken. An if used as a statement gives way
+// to the statements of that branch, unless they declare names, which would
+// then leak into the enclosing scope. An if used as a value is replaced
+// only when the branch is a single expression, as there is no literal for
+// the null a missing else gives.
+func EliminateDeadBranches(program *ast.Program) bool {
+	changed := false
+
+	ast.Apply(program, nil, func(cursor *ast.Cursor) bool {
+		switch node := cursor.Node().(type) {
+		case *ast.IfExpression:
+			taken, ok := branch(node)
+
+			if ok && taken != nil && len(taken.Statements) == 1 {
+				if statement, ok := taken.Statements[0].(*ast.ExpressionStatement); ok {
+					cursor.Replace(statement.Expression)
+					changed = true
+				}
+			}
+
+		case *ast.ExpressionStatement:
+			expression, ok := node.Expression.(*ast.IfExpression)
+
+			if !ok || cursor.Index() < 0 {
+				return true
+			}
+
+			taken, ok := branch(expression)
+
+			if !ok || (taken != nil && declares(taken)) {
+				return true
+			}
+
+			// The last statement of a block gives its value, so it can only
+			// be replaced by statements that end in a value themselves.
+			siblings, ok := statements(cursor.Parent())
+
+			if !ok || (cursor.Index() == len(siblings)-1 && !endsInExpression(taken)) {
+				return true
+			}
+
+			if taken != nil {
+				for _, statement := range taken.Statements {
+					cursor.InsertBefore(statement)
+				}
+			}
+
+			cursor.Delete()
+			changed = true
+		}
+
+		return true
+	})
+
+	return changed
+}
+
+// branch returns the block taken by an if expression with a literal
+// condition, which is nil when the condition is false and there is no
+// else. ok is false when the condition is not a literal.
+func branch(expression *ast.IfExpression) (taken *ast.BlockStatement, ok bool) {
+	condition, ok := expression.Condition.(*ast.Boolean)
+
+	if !ok {
+		return nil, false
+	}
+
+	if condition.Value {
+		return expression.Consequence, true
+	}
+
+	return expression.Alternative, true
+}
+
+func declares(block *ast.BlockStatement) bool {
+	for _, statement := range block.Statements {
+		switch statement.(type) {
+		case *ast.LetStatement, *ast.StructStatement, *ast.EnumStatement:
+			return true
+		}
+	}
+
+	return false
+}
+
+func statements(node ast.Node) ([]ast.Statement, bool) {
+	switch node := node.(type) {
+	case *ast.Program:
+		return node.Statemens, true
+	case *ast.BlockStatement:
+		return node.Statements, true
+	default:
+		return nil, false
+	}
+}
+
+func endsInExpression(block *ast.BlockStatement) bool {
+	if block == nil || len(block.Statements) == 0 {
+		return false
+	}
+
+	_, ok := block.Statements[len(block.Statements)-1].(*ast.ExpressionStatement)
+
+	return ok
+}
@@ -0,0 +1,180 @@
+package optimizer
+
+import (
+	"math"
+	"monkey/ast"
+	"monkey/token"
+	"strconv"
+)
+
+// FoldConstants replaces prefix and infix operations on literals by their
+// result: integer arithmetic and comparisons, ! and comparisons of
+// booleans, and concatenation and comparisons of strings. Integers wrap
+// around on overflow like the int64 arithmetic of the interpreter, and a
+// division by zero is left for the interpreter to report. Operations on
+// mismatched types are left alone for the same reason. A negative result
+// is written as a negated literal, as the parser reads -5.
+func FoldConstants(program *ast.Program) bool {
+	changed := false
+
+	ast.Apply(program, nil, func(cursor *ast.Cursor) bool {
+		var folded ast.Expression
+
+		switch expression := cursor.Node().(type) {
+		case *ast.PrefixExpression:
+			folded = foldPrefix(expression)
+		case *ast.InfixExpression:
+			folded = foldInfix(expression)
+		}
+
+		if folded != nil {
+			cursor.Replace(folded)
+			changed = true
+		}
+
+		return true
+	})
+
+	return changed
+}
+
+func foldPrefix(expression *ast.PrefixExpression) ast.Expression {
+	switch right := expression.Right.(type) {
+	case *ast.PrefixExpression:
+		// -5 itself is as folded as it gets, but -(-5) is not
+		if value, _, ok := integerValue(right); ok && expression.Operator == "-" {
+			return integer(-value, expression.Token)
+		}
+
+	case *ast.Boolean:
+		if expression.Operator == "!" {
+			return boolean(!right.Value, expression.Token)
+		}
+	}
+
+	return nil
+}
+
+// foldInfix folds an operation on two literals of the same type. The
+// result takes the position of the left operand, where the operation
+// starts.
+func foldInfix(expression *ast.InfixExpression) ast.Expression {
+	if a, position, ok := integerValue(expression.Left); ok {
+		b, _, ok := integerValue(expression.Right)
+
+		if !ok {
+			return nil
+		}
+
+		switch expression.Operator {
+		case "+":
+			return integer(a+b, position)
+		case "-":
+			return integer(a-b, position)
+		case "*":
+			return integer(a*b, position)
+		case "/":
+			if b != 0 {
+				return integer(a/b, position)
+			}
+		case "<":
+			return boolean(a < b, position)
+		case ">":
+			return boolean(a > b, position)
+		case "==":
+			return boolean(a == b, position)
+		case "!=":
+			return boolean(a != b, position)
+		}
+
+		return nil
+	}
+
+	switch left := expression.Left.(type) {
+	case *ast.Boolean:
+		right, ok := expression.Right.(*ast.Boolean)
+
+		if !ok {
+			return nil
+		}
+
+		switch expression.Operator {
+		case "==":
+			return boolean(left.Value == right.Value, left.Token)
+		case "!=":
+			return boolean(left.Value != right.Value, left.Token)
+		}
+
+	case *ast.StringLiteral:
+		right, ok := expression.Right.(*ast.StringLiteral)
+
+		if !ok {
+			return nil
+		}
+
+		switch expression.Operator {
+		case "+":
+			return stringLiteral(left.Value+right.Value, left.Token)
+		case "==":
+			return boolean(left.Value == right.Value, left.Token)
+		case "!=":
+			return boolean(left.Value != right.Value, left.Token)
+		}
+	}
+
+	return nil
+}
+
+// integerValue returns the value of an integer literal or a negated one,
+// and the token it starts with.
+func integerValue(expression ast.Expression) (int64, token.Token, bool) {
+	switch expression := expression.(type) {
+	case *ast.IntegerLiteral:
+		return expression.Value, expression.Token, true
+	case *ast.PrefixExpression:
+		if literal, ok := expression.Right.(*ast.IntegerLiteral); ok && expression.Operator == "-" {
+			return -literal.Value, expression.Token, true
+		}
+	}
+
+	return 0, token.Token{}, false
+}
+
+// integer returns an integer literal, negated if value is negative. The
+// smallest int64 has no literal to negate, so integer returns nil for it
+// and the operation is left unfolded.
+func integer(value int64, position token.Token) ast.Expression {
+	if value == math.MinInt64 {
+		return nil
+	}
+
+	if value < 0 {
+		digits := position
+
+		if digits.Line > 0 {
+			digits.Column++
+		}
+
+		return &ast.PrefixExpression{
+			Token:    at(token.Token{Type: token.MINUS, Literal: "-"}, position),
+			Operator: "-",
+			Right:    integer(-value, digits),
+		}
+	}
+
+	return &ast.IntegerLiteral{Token: at(token.Token{Type: token.INT, Literal: strconv.FormatInt(value, 10)}, position), Value: value}
+}
+
+func stringLiteral(value string, position token.Token) *ast.StringLiteral {
+	return &ast.StringLiteral{Token: at(token.Token{Type: token.STRING, Literal: value}, position), Value: value}
+}
+
+func boolean(value bool, position token.Token) *ast.Boolean {
+	tok := token.Token{Type: token.FALSE, Literal: "false"}
+
+	if value {
+		tok = token.Token{Type: token.TRUE, Literal: "true"}
+	}
+
+	return &ast.Boolean{Token: at(tok, position), Value: value}
+}
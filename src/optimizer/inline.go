@@ -0,0 +1,89 @@
+package optimizer
+
+import (
+	"monkey/ast"
+	"monkey/resolver"
+	"monkey/token"
+)
+
+// InlineConstants replaces the uses of const bindings whose value is a
+// literal by that literal, and removes the bindings. Exported bindings are
+// kept for importers, and uses the resolver reports an error for, such as
+// a use before the declaration, are left alone.
+func InlineConstants(program *ast.Program) bool {
+	resolution := resolver.Resolve(program)
+
+	values := map[*resolver.Declaration]ast.Expression{}
+
+	ast.Inspect(program, func(node ast.Node) bool {
+		if statement, ok := node.(*ast.LetStatement); ok && statement.IsConst() &&
+			statement.Name != nil && literal(statement.Value) {
+			values[resolution.Declarations[statement.Name]] = statement.Value
+		}
+
+		return true
+	})
+
+	if len(values) == 0 {
+		return false
+	}
+
+	invalid := map[[2]int]bool{}
+
+	for _, err := range resolution.Errors {
+		invalid[[2]int{err.Line, err.Column}] = true
+	}
+
+	// A binding with a use that cannot be inlined has to stay in place.
+	for declaration := range values {
+		for _, use := range declaration.Uses {
+			if invalid[[2]int{use.Token.Line, use.Token.Column}] {
+				delete(values, declaration)
+				break
+			}
+		}
+	}
+
+	changed := false
+
+	ast.Apply(program, nil, func(cursor *ast.Cursor) bool {
+		switch node := cursor.Node().(type) {
+		case *ast.Identifier:
+			value, ok := values[resolution.Uses[node]]
+
+			if !ok {
+				return true
+			}
+
+			cursor.Replace(moved(ast.Clone(value).(ast.Expression), node.Token))
+			changed = true
+
+		case *ast.LetStatement:
+			if _, ok := values[resolution.Declarations[node.Name]]; ok && cursor.Index() >= 0 {
+				cursor.Delete()
+				changed = true
+			}
+		}
+
+		return true
+	})
+
+	return changed
+}
+
+// moved returns literal with the position of the use it replaces.
+func moved(literal ast.Expression, position token.Token) ast.Expression {
+	switch literal := literal.(type) {
+	case *ast.IntegerLiteral:
+		literal.Token = at(literal.Token, position)
+	case *ast.Boolean:
+		literal.Token = at(literal.Token, position)
+	case *ast.StringLiteral:
+		literal.Token = at(literal.Token, position)
+	case *ast.PrefixExpression:
+		literal.Token = at(literal.Token, position)
+		moved(literal.Right, token.Token{Line: position.Line, Column: position.Column + 1})
+	}
+
+	return literal
+}
@@ -0,0 +1,55 @@
+// Package optimizer rewrites syntax trees into equivalent, cheaper ones.
+//
+// Each transform is a pass that changes a program in place and reports
+// whether it changed anything, so that it can be run and tested on its
+// own. Optimize runs all of them on a copy of a program.
+package optimizer
+
+import (
+	"monkey/ast"
+	"monkey/token"
+)
+
+// A Pass rewrites program in place and reports whether it changed it.
+type Pass func(program *ast.Program) bool
+
+// Passes lists the passes Optimize runs, in order.
+var Passes = []Pass{FoldConstants, InlineConstants, EliminateDeadBranches}
+
+// Optimize returns an optimized copy of program. It repeats the passes
+// until none of them changes anything, as each pass can enable the others:
+// inlining a constant can make an if condition constant.
+func Optimize(program *ast.Program) *ast.Program {
+	optimized := ast.Clone(program).(*ast.Program)
+
+	for changed := true; changed; {
+		changed = false
+
+		for _, pass := range Passes {
+			if pass(optimized) {
+				changed = true
+			}
+		}
+	}
+
+	return optimized
+}
+
+// literal reports whether expression is an integer, boolean or string
+// literal, or a negated integer literal.
+func literal(expression ast.Expression) bool {
+	switch expression.(type) {
+	case *ast.IntegerLiteral, *ast.Boolean, *ast.StringLiteral:
+		return true
+	default:
+		_, _, ok := integerValue(expression)
+		return ok
+	}
+}
+
+// at returns tok with the line and column of position.
+func at(tok token.Token, position token.Token) token.Token {
+	tok.Line, tok.Column = position.Line, position.Column
+
+	return tok
+}
@@ -0,0 +1,159 @@
+package optimizer
+
+import (
+	"monkey/ast"
+	"monkey/formatter"
+	"monkey/lexer"
+	"monkey/parser"
+	"testing"
+)
+
+func parse(t *testing.T, input string) *ast.Program {
+	t.Helper()
+
+	newParser := parser.NewParser(lexer.NewLexer(input))
+	program := newParser.ParseProgram()
+
+	if errors := newParser.Errors(); len(errors) > 0 {
+		t.Fatalf("parser errors for %q: %v", input, errors)
+	}
+
+	return program
+}
+
+type passTest struct {
+	input    string
+	expected string
+}
+
+func testPass(t *testing.T, pass Pass, tests []passTest) {
+	t.Helper()
+
+	for _, tt := range tests {
+		program := parse(t, tt.input)
+		expected := parse(t, tt.expected).String()
+
+		changed := pass(program)
+
+		if got := program.String(); got != expected {
+			t.Errorf("wrong result for %q.\nexpected=%q\ngot=     %q", tt.input, expected, got)
+		}
+
+		if changed != (tt.input != tt.expected) {
+			t.Errorf("pass reported changed=%t for %q", changed, tt.input)
+		}
+	}
+}
+
+func TestFoldConstants(t *testing.T) {
+	testPass(t, FoldConstants, []passTest{
+		{"1 + 2 * 3;", "7;"},
+		{"(10 - 4) / 3;", "2;"},
+		{"-(-5);", "5;"},
+		{"1 / 0;", "1 / 0;"},
+		{"1 < 2 == true;", "true;"},
+		{"!true != false;", "false;"},
+		{`"mon" + "key";`, `"monkey";`},
+		{`"a" == "b";`, "false;"},
+		{"1 + true;", "1 + true;"},
+		{"x + 1 * 2;", "x + 2;"},
+	})
+}
+
+func TestFoldingWrapsAround(t *testing.T) {
+	testPass(t, FoldConstants, []passTest{
+		{"9223372036854775807 + 9223372036854775807;", "-2;"},
+		{"-9223372036854775807 - 3;", "9223372036854775806;"},
+		{"9223372036854775807 * -2;", "2;"},
+		{"-(-9223372036854775807);", "9223372036854775807;"},
+		// the smallest int64 has no literal to negate
+		{"9223372036854775807 + 1;", "9223372036854775807 + 1;"},
+		{"-9223372036854775807 - 1;", "-9223372036854775807 - 1;"},
+	})
+}
+
+func TestFoldedProgramsParse(t *testing.T) {
+	inputs := []string{
+		"let x = 1 - 6;",
+		"let y = 9223372036854775807 + 9223372036854775807 * 2;",
+		"let z = 9223372036854775807 + 1;",
+		"f(0 - 1, -(2 * 3), --4);",
+	}
+
+	for _, input := range inputs {
+		program := parse(t, input)
+		FoldConstants(program)
+
+		source, err := formatter.Source([]byte(program.String()), formatter.Options{})
+
+		if err != nil {
+			t.Fatalf("folded %q does not format: %v", input, err)
+		}
+
+		if reparsed := parse(t, string(source)); !ast.Equal(program, reparsed, ast.EqualOptions{IgnorePositions: true}) {
+			t.Errorf("folded %q reads back differently.\nfolded=  %q\nreparsed=%q", input, program.String(), reparsed.String())
+		}
+	}
+}
+
+func TestFoldKeepsPositions(t *testing.T) {
+	program := parse(t, "let x =\n  2 * 3;")
+	FoldConstants(program)
+
+	value := program.Statemens[0].(*ast.LetStatement).Value.(*ast.IntegerLiteral)
+
+	if value.Token.Line != 2 || value.Token.Column != 3 || value.Token.Literal != "6" {
+		t.Errorf("wrong token for the folded value. got=%+v", value.Token)
+	}
+}
+
+func TestEliminateDeadBranches(t *testing.T) {
+	testPass(t, EliminateDeadBranches, []passTest{
+		{"let x = if (true) { 1 } else { 2 };", "let x = 1;"},
+		{"let x = if (false) { 1 } else { 2 };", "let x = 2;"},
+		{"let x = if (false) { 1 };", "let x = if (false) { 1 };"},
+		{"if (true) { f(); g(); }; h();", "f(); g(); h();"},
+		{"if (false) { f(); }; h();", "h();"},
+		{"if (false) { f(); } else if (true) { g(); }; h();", "g(); h();"},
+		{"if (true) { let y = 1; y; }; h();", "if (true) { let y = 1; y; }; h();"},
+		{"fn() { if (false) { 1 } }", "fn() { if (false) { 1 } }"},
+		{"fn() { if (true) { f(); 1 } }", "fn() { f(); 1 }"},
+		{"if (x) { 1 } else { 2 };", "if (x) { 1 } else { 2 };"},
+	})
+}
+
+func TestInlineConstants(t *testing.T) {
+	testPass(t, InlineConstants, []passTest{
+		{"const n = 2; n * n;", "2 * 2;"},
+		{"const n = -1; n;", "-1;"},
+		{`const s = "a"; let f = fn() { s };`, `let f = fn() { "a" };`},
+		{"const n = 1; let f = fn(n) { n };", "let f = fn(n) { n };"},
+		{"export const n = 1; n;", "export const n = 1; 1;"},
+		{"const a = [1]; a;", "const a = [1]; a;"},
+		{"let n = 1; n;", "let n = 1; n;"},
+		{"let f = fn() { n }; const n = 1;", "let f = fn() { 1 };"},
+		{"n; const n = 1;", "n; const n = 1;"},
+	})
+}
+
+func TestOptimize(t *testing.T) {
+	input := `
+const debug = false;
+const size = 4 * 1024;
+if (!debug) { run(size - 1); };
+if (debug) { log("size: " + "4k"); };
+done();
+`
+	program := parse(t, input)
+	before := program.String()
+
+	optimized := Optimize(program)
+
+	if expected := parse(t, "run(4095); done();").String(); optimized.String() != expected {
+		t.Errorf("wrong result.\nexpected=%q\ngot=     %q", expected, optimized.String())
+	}
+
+	if program.String() != before {
+		t.Errorf("Optimize changed its input. got=%q", program.String())
+	}
+}
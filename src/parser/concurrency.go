@@ -0,0 +1,127 @@
+package parser
+
+import (
+	"fmt"
+	"monkey/ast"
+	"monkey/token"
+)
+
+func (parser *Parser) parseSpawnExpression() ast.Expression {
+	expression := &ast.SpawnExpression{Token: parser.currentToken}
+
+	parser.nextToken()
+
+	call, ok := parser.parserExpression(PREFIX).(*ast.CallExpression)
+
+	if !ok {
+		parser.errors = append(parser.errors, "spawn must be followed by a function call")
+
+		return nil
+	}
+
+	expression.Call = call
+
+	return expression
+}
+
+func (parser *Parser) parseSelectStatement() *ast.SelectStatement {
+	statement := &ast.SelectStatement{Token: parser.currentToken}
+	statement.Cases = []*ast.SelectCase{}
+
+	if !parser.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	for !parser.peekTokenIs(token.RBRACE) {
+		parser.nextToken()
+
+		switch parser.currentToken.Type {
+		case token.CASE:
+			selectCase := parser.parseSelectCase()
+
+			if selectCase == nil {
+				return nil
+			}
+
+			statement.Cases = append(statement.Cases, selectCase)
+
+		case token.DEFAULT:
+			if statement.Default != nil {
+				parser.errors = append(parser.errors, "multiple defaults in select")
+
+				return nil
+			}
+
+			if !parser.expectPeek(token.LBRACE) {
+				return nil
+			}
+
+			statement.Default = parser.parseBlockStatement()
+
+		default:
+			message := fmt.Sprintf("expected case or default in select, got %s instead", parser.currentToken.Type)
+			parser.errors = append(parser.errors, message)
+
+			return nil
+		}
+	}
+
+	parser.nextToken()
+
+	if len(statement.Cases) == 0 {
+		parser.errors = append(parser.errors, "select needs at least one case")
+
+		return nil
+	}
+
+	if parser.peekTokenIs(token.SEMICOLON) {
+		parser.nextToken()
+	}
+
+	return statement
+}
+
+// parseSelectCase parses `case [name =] send(...)|recv(...) { body }`.
+func (parser *Parser) parseSelectCase() *ast.SelectCase {
+	selectCase := &ast.SelectCase{Token: parser.currentToken}
+
+	parser.nextToken()
+
+	if parser.currentTokenIs(token.IDENT) && parser.peekTokenIs(token.ASSIGN) {
+		selectCase.Binding = &ast.Identifier{Token: parser.currentToken, Value: parser.currentToken.Literal}
+
+		parser.nextToken()
+		parser.nextToken()
+	}
+
+	operation, ok := parser.parserExpression(LOWEST).(*ast.CallExpression)
+
+	if !ok || !isChannelOperation(operation) {
+		parser.errors = append(parser.errors, "select case must be a send(...) or recv(...) call")
+
+		return nil
+	}
+
+	if selectCase.Binding != nil && operation.Function.String() != "recv" {
+		message := fmt.Sprintf("cannot bind %s to the result of send", selectCase.Binding.Value)
+		parser.errors = append(parser.errors, message)
+
+		return nil
+	}
+
+	selectCase.Operation = operation
+
+	if !parser.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	selectCase.Body = parser.parseBlockStatement()
+
+	return selectCase
+}
+
+func isChannelOperation(call *ast.CallExpression) bool {
+	function, ok := call.Function.(*ast.Identifier)
+
+	return ok && !call.Piped && (function.Value == "send" || function.Value == "recv")
+}
@@ -0,0 +1,163 @@
+package parser
+
+import (
+	"fmt"
+	"monkey/ast"
+	"monkey/token"
+)
+
+func (parser *Parser) parseStructStatement() *ast.StructStatement {
+	statement := &ast.StructStatement{Token: parser.currentToken}
+
+	if !parser.expectPeek(token.IDENT) {
+		return nil
+	}
+
+	statement.Name = &ast.Identifier{Token: parser.currentToken, Value: parser.currentToken.Literal}
+
+	if !parser.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	statement.Fields = parser.parseFieldList(statement.Name.Value)
+
+	if statement.Fields == nil {
+		return nil
+	}
+
+	if parser.peekTokenIs(token.SEMICOLON) {
+		parser.nextToken()
+	}
+
+	return statement
+}
+
+// parseFieldList parses `name, name, ...` up to the closing } and rejects
+// duplicate names.
+func (parser *Parser) parseFieldList(owner string) []*ast.Identifier {
+	fields := []*ast.Identifier{}
+	seen := map[string]bool{}
+
+	for !parser.peekTokenIs(token.RBRACE) {
+		if !parser.expectPeek(token.IDENT) {
+			return nil
+		}
+
+		field := &ast.Identifier{Token: parser.currentToken, Value: parser.currentToken.Literal}
+
+		if seen[field.Value] {
+			message := fmt.Sprintf("duplicate field %s in %s", field.Value, owner)
+			parser.errors = append(parser.errors, message)
+
+			return nil
+		}
+
+		seen[field.Value] = true
+		fields = append(fields, field)
+
+		if !parser.peekTokenIs(token.RBRACE) && !parser.expectPeek(token.COMMA) {
+			return nil
+		}
+	}
+
+	parser.nextToken()
+
+	return fields
+}
+
+func (parser *Parser) parseEnumStatement() *ast.EnumStatement {
+	statement := &ast.EnumStatement{Token: parser.currentToken}
+
+	if !parser.expectPeek(token.IDENT) {
+		return nil
+	}
+
+	statement.Name = &ast.Identifier{Token: parser.currentToken, Value: parser.currentToken.Literal}
+
+	if !parser.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	statement.Variants = []*ast.EnumVariant{}
+
+	for !parser.peekTokenIs(token.RBRACE) {
+		if !parser.expectPeek(token.IDENT) {
+			return nil
+		}
+
+		variant := &ast.EnumVariant{
+			Name:   &ast.Identifier{Token: parser.currentToken, Value: parser.currentToken.Literal},
+			Fields: []*ast.Identifier{},
+		}
+
+		if statement.Variant(variant.Name.Value) != nil {
+			message := fmt.Sprintf("duplicate variant %s in enum %s", variant.Name.Value, statement.Name.Value)
+			parser.errors = append(parser.errors, message)
+
+			return nil
+		}
+
+		if parser.peekTokenIs(token.LPAREN) {
+			parser.nextToken()
+
+			variant.Fields = parser.parseVariantFields(statement.Name.Value + "." + variant.Name.Value)
+
+			if variant.Fields == nil {
+				return nil
+			}
+		}
+
+		statement.Variants = append(statement.Variants, variant)
+
+		if !parser.peekTokenIs(token.RBRACE) && !parser.expectPeek(token.COMMA) {
+			return nil
+		}
+	}
+
+	parser.nextToken()
+
+	if len(statement.Variants) == 0 {
+		message := fmt.Sprintf("enum %s needs at least one variant", statement.Name.Value)
+		parser.errors = append(parser.errors, message)
+
+		return nil
+	}
+
+	if parser.peekTokenIs(token.SEMICOLON) {
+		parser.nextToken()
+	}
+
+	return statement
+}
+
+// parseVariantFields parses the `(a, b)` payload of an enum variant.
+func (parser *Parser) parseVariantFields(owner string) []*ast.Identifier {
+	fields := []*ast.Identifier{}
+	seen := map[string]bool{}
+
+	for !parser.peekTokenIs(token.RPAREN) {
+		if !parser.expectPeek(token.IDENT) {
+			return nil
+		}
+
+		field := &ast.Identifier{Token: parser.currentToken, Value: parser.currentToken.Literal}
+
+		if seen[field.Value] {
+			message := fmt.Sprintf("duplicate field %s in %s", field.Value, owner)
+			parser.errors = append(parser.errors, message)
+
+			return nil
+		}
+
+		seen[field.Value] = true
+		fields = append(fields, field)
+
+		if !parser.peekTokenIs(token.RPAREN) && !parser.expectPeek(token.COMMA) {
+			return nil
+		}
+	}
+
+	parser.nextToken()
+
+	return fields
+}
@@ -0,0 +1,429 @@
+package parser
+
+import (
+	"fmt"
+	"monkey/ast"
+	"monkey/token"
+)
+
+func (parser *Parser) parseFunctionParameters() []*ast.Parameter {
+	parameters := []*ast.Parameter{}
+
+	if parser.peekTokenIs(token.RPAREN) {
+		parser.nextToken()
+
+		return parameters
+	}
+
+	parser.nextToken()
+
+	for {
+		parameter := parser.parseParameter()
+
+		if parameter == nil {
+			return nil
+		}
+
+		parameters = append(parameters, parameter)
+
+		if !parser.peekTokenIs(token.COMMA) {
+			break
+		}
+
+		if parameter.Rest {
+			message := fmt.Sprintf("rest parameter %s must be the last parameter", parameter.String())
+			parser.errors = append(parser.errors, message)
+
+			return nil
+		}
+
+		parser.nextToken()
+		parser.nextToken()
+	}
+
+	if !parser.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	if !parser.checkParameters(parameters) {
+		return nil
+	}
+
+	return parameters
+}
+
+func (parser *Parser) parseParameter() *ast.Parameter {
+	if parser.currentTokenIs(token.ELLIPSIS) {
+		if !parser.expectPeek(token.IDENT) {
+			return nil
+		}
+
+		parameter := &ast.Parameter{Pattern: &ast.Identifier{Token: parser.currentToken, Value: parser.currentToken.Literal}, Rest: true}
+
+		var ok bool
+
+		if parameter.Type, ok = parser.parseTypeAnnotation(); !ok {
+			return nil
+		}
+
+		if parser.peekTokenIs(token.ASSIGN) {
+			message := fmt.Sprintf("rest parameter %s cannot have a default value", parameter.String())
+			parser.errors = append(parser.errors, message)
+
+			return nil
+		}
+
+		return parameter
+	}
+
+	pattern := parser.parsePattern()
+
+	if pattern == nil {
+		return nil
+	}
+
+	parameter := &ast.Parameter{Pattern: pattern}
+
+	var ok bool
+
+	if parameter.Type, ok = parser.parseTypeAnnotation(); !ok {
+		return nil
+	}
+
+	if parser.peekTokenIs(token.ASSIGN) {
+		parser.nextToken()
+		parser.nextToken()
+
+		// a default value is not part of any function body, so a yield in
+		// it must not turn the enclosing function into a generator
+		parser.enterFunction(nil)
+		parameter.Default = parser.parserExpression(LOWEST)
+		parser.leaveFunction()
+	}
+
+	return parameter
+}
+
+// checkParameters rejects duplicate parameter names and required
+// parameters that follow a parameter with a default value.
+func (parser *Parser) checkParameters(parameters []*ast.Parameter) bool {
+	seen := map[string]bool{}
+	var defaulted *ast.Parameter
+
+	for _, parameter := range parameters {
+		for _, name := range ast.PatternNames(parameter.Pattern) {
+			if seen[name.Value] {
+				message := fmt.Sprintf("duplicate parameter name %s", name.Value)
+				parser.errors = append(parser.errors, message)
+
+				return false
+			}
+
+			seen[name.Value] = true
+		}
+
+		if parameter.Default != nil {
+			defaulted = parameter
+		} else if !parameter.Rest && defaulted != nil {
+			message := fmt.Sprintf("required parameter %s follows parameter with default value %s",
+				parameter.String(), defaulted.String())
+			parser.errors = append(parser.errors, message)
+
+			return false
+		}
+	}
+
+	return true
+}
+
+func (parser *Parser) parseCallExpression(function ast.Expression) ast.Expression {
+	expression := &ast.CallExpression{Token: parser.currentToken, Function: function}
+	expression.Arguments = parser.parseCallArguments()
+
+	return expression
+}
+
+func (parser *Parser) parseCallArguments() []ast.Expression {
+	args := []ast.Expression{}
+
+	if parser.peekTokenIs(token.RPAREN) {
+		parser.nextToken()
+
+		return args
+	}
+
+	named := map[string]bool{}
+
+	for {
+		parser.nextToken()
+
+		argument := parser.parseCallArgument()
+
+		if argument == nil {
+			return nil
+		}
+
+		if namedArgument, ok := argument.(*ast.NamedArgument); ok {
+			if named[namedArgument.Name.Value] {
+				message := fmt.Sprintf("duplicate named argument %s", namedArgument.Name.Value)
+				parser.errors = append(parser.errors, message)
+
+				return nil
+			}
+
+			named[namedArgument.Name.Value] = true
+		} else if len(named) > 0 {
+			message := fmt.Sprintf("positional argument %s follows named argument", argument.String())
+			parser.errors = append(parser.errors, message)
+
+			return nil
+		}
+
+		args = append(args, argument)
+
+		if !parser.peekTokenIs(token.COMMA) {
+			break
+		}
+
+		parser.nextToken()
+	}
+
+	if !parser.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	return args
+}
+
+func (parser *Parser) parseCallArgument() ast.Expression {
+	switch {
+	case parser.currentTokenIs(token.ELLIPSIS):
+		spread := &ast.SpreadExpression{Token: parser.currentToken}
+
+		parser.nextToken()
+		spread.Value = parser.parserExpression(LOWEST)
+
+		if spread.Value == nil {
+			return nil
+		}
+
+		return spread
+
+	case parser.currentTokenIs(token.IDENT) && parser.peekTokenIs(token.COLON):
+		name := &ast.Identifier{Token: parser.currentToken, Value: parser.currentToken.Literal}
+
+		parser.nextToken()
+		argument := &ast.NamedArgument{Token: parser.currentToken, Name: name}
+
+		parser.nextToken()
+		argument.Value = parser.parserExpression(LOWEST)
+
+		if argument.Value == nil {
+			return nil
+		}
+
+		return argument
+
+	default:
+		return parser.parserExpression(LOWEST)
+	}
+}
+
+// isArrowParameterList reports whether the parenthesized group starting at
+// the current ( token is the parameter list of an arrow function. It scans
+// a copy of the lexer only as long as the tokens can belong to patterns and
+// type annotations: the list ends in ) followed by =>, or reaches the = of a
+// default value, which no grouped expression contains. Any other token
+// ends the scan early, so nested groups are not rescanned to their end.
+func (parser *Parser) isArrowParameterList() bool {
+	probe := parser.lexer.Clone()
+	depth := 1
+	previous := parser.currentToken
+
+	for tok := parser.peekToken; ; previous, tok = tok, probe.NextToken() {
+		switch tok.Type {
+		case token.IDENT, token.COMMA, token.COLON, token.ELLIPSIS, token.FUNCTION, token.THIN_ARROW:
+		case token.LBRACKET, token.LBRACE:
+			depth++
+		case token.LPAREN:
+			// parentheses only nest in parameters around function types
+			if previous.Type != token.FUNCTION {
+				return false
+			}
+
+			depth++
+		case token.RBRACKET, token.RBRACE:
+			if depth == 1 {
+				return false
+			}
+
+			depth--
+		case token.RPAREN:
+			depth--
+
+			if depth == 0 {
+				return probe.NextToken().Type == token.ARROW
+			}
+		case token.ASSIGN:
+			return depth == 1
+		default:
+			return false
+		}
+	}
+}
+
+// parseArrowFunction desugars `params => body` into a function literal. An
+// expression body becomes a block holding a single expression statement.
+// The literal takes the position of start, the first token of params.
+func (parser *Parser) parseArrowFunction(start token.Token, parameters []*ast.Parameter) ast.Expression {
+	if !parser.expectPeek(token.ARROW) {
+		return nil
+	}
+
+	literal := &ast.FunctionLiteral{
+		Token:      token.Token{Type: token.FUNCTION, Literal: "fn", Line: start.Line, Column: start.Column},
+		Parameters: parameters,
+		IsArrow:    true,
+	}
+
+	parser.nextToken()
+
+	parser.enterFunction(literal)
+	defer parser.leaveFunction()
+
+	if parser.currentTokenIs(token.LBRACE) {
+		literal.Body = parser.parseBlockStatement()
+
+		return literal
+	}
+
+	statement := &ast.ExpressionStatement{Token: parser.currentToken}
+	statement.Expression = parser.parserExpression(LOWEST)
+
+	if statement.Expression == nil {
+		return nil
+	}
+
+	literal.Body = &ast.BlockStatement{Token: statement.Token, Statements: []ast.Statement{statement}}
+
+	return literal
+}
+
+// parsePipelineExpression desugars `left |> f(args)` into f(left, args) and
+// `left |> f` into f(left). A parenthesized call is a callee like any
+// other: `left |> (f(args))` becomes (f(args))(left).
+func (parser *Parser) parsePipelineExpression(left ast.Expression) ast.Expression {
+	pipe := parser.currentToken
+	precedence := parser.currentPrecedence()
+
+	parser.nextToken()
+
+	right := parser.parserExpression(precedence)
+
+	if right == nil {
+		return nil
+	}
+
+	if call, ok := right.(*ast.CallExpression); ok && !call.Piped && right != parser.group {
+		return &ast.CallExpression{
+			Token:     call.Token,
+			Function:  call.Function,
+			Arguments: append([]ast.Expression{left}, call.Arguments...),
+			Piped:     true,
+		}
+	}
+
+	return &ast.CallExpression{
+		Token:     pipe,
+		Function:  right,
+		Arguments: []ast.Expression{left},
+		Piped:     true,
+	}
+}
+
+// enterFunction records that the parser is inside the body of literal until
+// the matching leaveFunction. literal is nil inside a parameter default.
+func (parser *Parser) enterFunction(literal *ast.FunctionLiteral) {
+	parser.functions = append(parser.functions, literal)
+}
+
+func (parser *Parser) leaveFunction() {
+	parser.functions = parser.functions[:len(parser.functions)-1]
+}
+
+func (parser *Parser) parseDeferStatement() *ast.DeferStatement {
+	statement := &ast.DeferStatement{Token: parser.currentToken}
+
+	if parser.peekEndsExpression() {
+		parser.errors = append(parser.errors, "defer needs a call")
+
+		if parser.peekTokenIs(token.SEMICOLON) {
+			parser.nextToken()
+		}
+
+		return nil
+	}
+
+	parser.nextToken()
+
+	statement.Call = parser.parserExpression(LOWEST)
+
+	if statement.Call == nil {
+		return nil
+	}
+
+	if parser.peekTokenIs(token.SEMICOLON) {
+		parser.nextToken()
+	}
+
+	// the call is parsed anyway so that parsing resumes after it
+	if len(parser.functions) == 0 {
+		parser.errors = append(parser.errors, "defer is only allowed inside a function body")
+
+		return nil
+	}
+
+	return statement
+}
+
+// parseYieldExpression parses `yield value` or a bare `yield`, and turns
+// the enclosing function into a generator.
+func (parser *Parser) parseYieldExpression() ast.Expression {
+	expression := &ast.YieldExpression{Token: parser.currentToken}
+
+	if len(parser.functions) == 0 {
+		parser.errors = append(parser.errors, "yield is only allowed inside a function body")
+
+		return nil
+	}
+
+	function := parser.functions[len(parser.functions)-1]
+
+	if function == nil {
+		parser.errors = append(parser.errors, "yield is not allowed in a parameter default")
+
+		return nil
+	}
+
+	function.IsGenerator = true
+
+	if parser.peekEndsExpression() {
+		return expression
+	}
+
+	parser.nextToken()
+
+	expression.Value = parser.parserExpression(LOWEST)
+
+	return expression
+}
+
+// peekEndsExpression reports whether the next token cannot start an operand.
+func (parser *Parser) peekEndsExpression() bool {
+	switch parser.peekToken.Type {
+	case token.SEMICOLON, token.RBRACE, token.RPAREN, token.RBRACKET, token.COMMA, token.EOF:
+		return true
+	default:
+		return false
+	}
+}
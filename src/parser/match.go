@@ -0,0 +1,147 @@
+package parser
+
+import (
+	"monkey/ast"
+	"monkey/token"
+	"unicode"
+	"unicode/utf8"
+)
+
+func (parser *Parser) parseMatchExpression() ast.Expression {
+	expression := &ast.MatchExpression{Token: parser.currentToken}
+
+	parser.nextToken()
+
+	expression.Subject = parser.parserExpression(LOWEST)
+
+	if expression.Subject == nil || !parser.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	expression.Arms = []*ast.MatchArm{}
+
+	for !parser.peekTokenIs(token.RBRACE) {
+		parser.nextToken()
+
+		arm := parser.parseMatchArm()
+
+		if arm == nil {
+			return nil
+		}
+
+		expression.Arms = append(expression.Arms, arm)
+
+		if !parser.peekTokenIs(token.RBRACE) && !parser.expectPeek(token.COMMA) {
+			return nil
+		}
+	}
+
+	parser.nextToken()
+
+	if len(expression.Arms) == 0 {
+		parser.errors = append(parser.errors, "match needs at least one arm")
+
+		return nil
+	}
+
+	return expression
+}
+
+func (parser *Parser) parseMatchArm() *ast.MatchArm {
+	pattern := parser.parseMatchPattern()
+
+	if pattern == nil || !parser.expectPeek(token.ARROW) {
+		return nil
+	}
+
+	arm := &ast.MatchArm{Token: parser.currentToken, Pattern: pattern}
+
+	parser.nextToken()
+
+	if parser.currentTokenIs(token.LBRACE) {
+		arm.Body = parser.parseBlockStatement()
+
+		return arm
+	}
+
+	statement := &ast.ExpressionStatement{Token: parser.currentToken}
+	statement.Expression = parser.parserExpression(LOWEST)
+
+	if statement.Expression == nil {
+		return nil
+	}
+
+	arm.Body = &ast.BlockStatement{Token: statement.Token, Statements: []ast.Statement{statement}}
+
+	return arm
+}
+
+// parseMatchPattern parses the pattern of a match arm. Besides the binding
+// patterns accepted by let, an arm may match a literal or an enum variant.
+// Variants are written Name, Name(bindings) or Enum.Name(bindings); a bare
+// identifier is a variant when it starts with an upper-case letter and a
+// binding otherwise.
+func (parser *Parser) parseMatchPattern() ast.Pattern {
+	switch parser.currentToken.Type {
+	case token.INT, token.STRING, token.TRUE, token.FALSE:
+		return &ast.LiteralPattern{Token: parser.currentToken, Value: parser.prefixParseFns[parser.currentToken.Type]()}
+
+	case token.IDENT:
+		if parser.peekTokenIs(token.DOT) || parser.peekTokenIs(token.LPAREN) || isVariantName(parser.currentToken.Literal) {
+			return parser.parseVariantPattern()
+		}
+	}
+
+	return parser.parsePattern()
+}
+
+func (parser *Parser) parseVariantPattern() ast.Pattern {
+	pattern := &ast.VariantPattern{Token: parser.currentToken}
+	name := &ast.Identifier{Token: parser.currentToken, Value: parser.currentToken.Literal}
+
+	if parser.peekTokenIs(token.DOT) {
+		parser.nextToken()
+
+		if !parser.expectPeek(token.IDENT) {
+			return nil
+		}
+
+		pattern.Enum = name
+		name = &ast.Identifier{Token: parser.currentToken, Value: parser.currentToken.Literal}
+	}
+
+	pattern.Variant = name
+	pattern.Bindings = []ast.Pattern{}
+
+	if !parser.peekTokenIs(token.LPAREN) {
+		return pattern
+	}
+
+	parser.nextToken()
+
+	for !parser.peekTokenIs(token.RPAREN) {
+		parser.nextToken()
+
+		binding := parser.parseMatchPattern()
+
+		if binding == nil {
+			return nil
+		}
+
+		pattern.Bindings = append(pattern.Bindings, binding)
+
+		if !parser.peekTokenIs(token.RPAREN) && !parser.expectPeek(token.COMMA) {
+			return nil
+		}
+	}
+
+	parser.nextToken()
+
+	return pattern
+}
+
+func isVariantName(name string) bool {
+	first, _ := utf8.DecodeRuneInString(name)
+
+	return unicode.IsUpper(first)
+}
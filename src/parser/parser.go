@@ -11,14 +11,31 @@ import (
 const (
 	_ int = iota
 	LOWEST
+	PIPELINE    // x |> f
 	EQUALS      // ==
 	LESSGREATER // > or <
 	SUM         // +
 	PRODUCT     // *
 	PREFIX      // -X or !X
 	CALL        // myFunction(X)
+	INDEX       // array[index]
 )
 
+var precedences = map[token.TokenType]int{
+	token.PIPE:     PIPELINE,
+	token.EQ:       EQUALS,
+	token.NOT_EQ:   EQUALS,
+	token.LT:       LESSGREATER,
+	token.GT:       LESSGREATER,
+	token.PLUS:     SUM,
+	token.MINUS:    SUM,
+	token.SLASH:    PRODUCT,
+	token.ASTERISK: PRODUCT,
+	token.LPAREN:   CALL,
+	token.DOT:      CALL,
+	token.LBRACKET: INDEX,
+}
+
 type Parser struct {
 	lexer  *lexer.Lexer
 	errors []string
@@ -28,6 +45,9 @@ type Parser struct {
 
 	prefixParseFns map[token.TokenType]prefixParseFn
 	infixParseFns  map[token.TokenType]infixParseFn
+
+	functions []*ast.FunctionLiteral // enclosing function literals, innermost last; nil for a parameter default
+	group     ast.Expression         // the expression of the last parenthesized group parsed
 }
 
 type (
@@ -42,8 +62,34 @@ func NewParser(lexer *lexer.Lexer) *Parser {
 	parser.prefixParseFns = make(map[token.TokenType]prefixParseFn)
 	parser.registerPrefix(token.IDENT, parser.parserIdentifier)
 	parser.registerPrefix(token.INT, parser.parseIntegerLiteral)
+	parser.registerPrefix(token.STRING, parser.parseStringLiteral)
+	parser.registerPrefix(token.TEMPLATE_START, parser.parseTemplateLiteral)
 	parser.registerPrefix(token.BANG, parser.parsePrefixExpression)
 	parser.registerPrefix(token.MINUS, parser.parsePrefixExpression)
+	parser.registerPrefix(token.FUNCTION, parser.parseFunctionLiteral)
+	parser.registerPrefix(token.LBRACKET, parser.parseArrayLiteral)
+	parser.registerPrefix(token.LBRACE, parser.parseHashLiteral)
+	parser.registerPrefix(token.LPAREN, parser.parseGroupedExpression)
+	parser.registerPrefix(token.TRUE, parser.parseBoolean)
+	parser.registerPrefix(token.FALSE, parser.parseBoolean)
+	parser.registerPrefix(token.YIELD, parser.parseYieldExpression)
+	parser.registerPrefix(token.SPAWN, parser.parseSpawnExpression)
+	parser.registerPrefix(token.MATCH, parser.parseMatchExpression)
+	parser.registerPrefix(token.IF, parser.parseIfExpression)
+
+	parser.infixParseFns = make(map[token.TokenType]infixParseFn)
+	parser.registerInfix(token.PLUS, parser.parseInfixExpression)
+	parser.registerInfix(token.MINUS, parser.parseInfixExpression)
+	parser.registerInfix(token.SLASH, parser.parseInfixExpression)
+	parser.registerInfix(token.ASTERISK, parser.parseInfixExpression)
+	parser.registerInfix(token.EQ, parser.parseInfixExpression)
+	parser.registerInfix(token.NOT_EQ, parser.parseInfixExpression)
+	parser.registerInfix(token.LT, parser.parseInfixExpression)
+	parser.registerInfix(token.GT, parser.parseInfixExpression)
+	parser.registerInfix(token.LPAREN, parser.parseCallExpression)
+	parser.registerInfix(token.PIPE, parser.parsePipelineExpression)
+	parser.registerInfix(token.DOT, parser.parseMemberExpression)
+	parser.registerInfix(token.LBRACKET, parser.parseIndexExpression)
 
 	parser.nextToken()
 	parser.nextToken()
@@ -52,6 +98,10 @@ func NewParser(lexer *lexer.Lexer) *Parser {
 }
 
 func (parser *Parser) Errors() []string {
+	if lexerErrors := parser.lexer.Errors(); len(lexerErrors) > 0 {
+		return append(append([]string{}, lexerErrors...), parser.errors...)
+	}
+
 	return parser.errors
 }
 
@@ -77,15 +127,51 @@ func (parser *Parser) ParseProgram() *ast.Program {
 	return program
 }
 
+// parseStatement returns a nil interface, not a nil pointer, when the
+// statement fails to parse, so that callers can skip it.
 func (parser *Parser) parseStatement() ast.Statement {
 	switch parser.currentToken.Type {
-	case token.LET:
-		return parser.parseLetStatement()
+	case token.LET, token.CONST:
+		if statement := parser.parseLetStatement(); statement != nil {
+			return statement
+		}
 	case token.RETURN:
 		return parser.parseReturnStatement()
+	case token.IMPORT:
+		if statement := parser.parseImportStatement(); statement != nil {
+			return statement
+		}
+	case token.EXPORT:
+		if statement := parser.parseExportStatement(); statement != nil {
+			return statement
+		}
+	case token.THROW:
+		return parser.parseThrowStatement()
+	case token.TRY:
+		if statement := parser.parseTryStatement(); statement != nil {
+			return statement
+		}
+	case token.DEFER:
+		if statement := parser.parseDeferStatement(); statement != nil {
+			return statement
+		}
+	case token.SELECT:
+		if statement := parser.parseSelectStatement(); statement != nil {
+			return statement
+		}
+	case token.STRUCT:
+		if statement := parser.parseStructStatement(); statement != nil {
+			return statement
+		}
+	case token.ENUM:
+		if statement := parser.parseEnumStatement(); statement != nil {
+			return statement
+		}
 	default:
 		return parser.parseExpressionStatement()
 	}
+
+	return nil
 }
 
 func (parser *Parser) parserExpression(precedence int) ast.Expression {
@@ -99,27 +185,68 @@ func (parser *Parser) parserExpression(precedence int) ast.Expression {
 
 	leftExp := prefix()
 
+	for !parser.peekTokenIs(token.SEMICOLON) && precedence < parser.peekPrecedence() {
+		infix := parser.infixParseFns[parser.peekToken.Type]
+
+		if infix == nil {
+			return leftExp
+		}
+
+		parser.nextToken()
+
+		leftExp = infix(leftExp)
+	}
+
 	return leftExp
 }
 
 func (parser *Parser) parserIdentifier() ast.Expression {
-	return &ast.Identifier{Token: parser.currentToken, Value: parser.currentToken.Literal}
+	identifier := &ast.Identifier{Token: parser.currentToken, Value: parser.currentToken.Literal}
+
+	if parser.peekTokenIs(token.ARROW) {
+		return parser.parseArrowFunction(identifier.Token, []*ast.Parameter{{Pattern: identifier}})
+	}
+
+	return identifier
 }
 
 func (parser *Parser) parseLetStatement() *ast.LetStatement {
 	statement := &ast.LetStatement{Token: parser.currentToken}
 
-	if !parser.expectPeek(token.IDENT) {
-		return nil
+	if parser.peekTokenIs(token.LBRACKET) || parser.peekTokenIs(token.LBRACE) {
+		parser.nextToken()
+		statement.Pattern = parser.parsePattern()
+
+		if statement.Pattern == nil {
+			return nil
+		}
+	} else {
+		if !parser.expectPeek(token.IDENT) {
+			return nil
+		}
+
+		statement.Name = &ast.Identifier{Token: parser.currentToken, Value: parser.currentToken.Literal}
 	}
 
-	statement.Name = &ast.Identifier{Token: parser.currentToken, Value: parser.currentToken.Literal}
+	var ok bool
+
+	if statement.Type, ok = parser.parseTypeAnnotation(); !ok {
+		return nil
+	}
 
 	if !parser.expectPeek(token.ASSIGN) {
 		return nil
 	}
 
-	for !parser.currentTokenIs(token.SEMICOLON) {
+	parser.nextToken()
+
+	statement.Value = parser.parserExpression(LOWEST)
+
+	if statement.Pattern != nil {
+		parser.checkPatternShape(statement.Pattern, statement.Value)
+	}
+
+	if parser.peekTokenIs(token.SEMICOLON) {
 		parser.nextToken()
 	}
 
@@ -129,9 +256,139 @@ func (parser *Parser) parseLetStatement() *ast.LetStatement {
 func (parser *Parser) parseReturnStatement() *ast.ReturnStatement {
 	statement := &ast.ReturnStatement{Token: parser.currentToken}
 
+	// a bare return leaves ReturnValue nil
+	if !parser.peekEndsExpression() {
+		parser.nextToken()
+
+		statement.ReturnValue = parser.parserExpression(LOWEST)
+	}
+
+	if parser.peekTokenIs(token.SEMICOLON) {
+		parser.nextToken()
+	}
+
+	return statement
+}
+
+func (parser *Parser) parseImportStatement() *ast.ImportStatement {
+	statement := &ast.ImportStatement{Token: parser.currentToken}
+
+	if !parser.expectPeek(token.STRING) {
+		return nil
+	}
+
+	statement.Path = &ast.StringLiteral{Token: parser.currentToken, Value: parser.currentToken.Literal}
+
+	if parser.peekTokenIs(token.AS) {
+		parser.nextToken()
+
+		if !parser.expectPeek(token.IDENT) {
+			return nil
+		}
+
+		statement.Alias = &ast.Identifier{Token: parser.currentToken, Value: parser.currentToken.Literal}
+	}
+
+	if parser.peekTokenIs(token.SEMICOLON) {
+		parser.nextToken()
+	}
+
+	return statement
+}
+
+func (parser *Parser) parseExportStatement() *ast.ExportStatement {
+	statement := &ast.ExportStatement{Token: parser.currentToken}
+
+	if parser.peekTokenIs(token.CONST) {
+		parser.nextToken()
+	} else if !parser.expectPeek(token.LET) {
+		return nil
+	}
+
+	statement.Declaration = parser.parseLetStatement()
+
+	if statement.Declaration == nil {
+		return nil
+	}
+
+	return statement
+}
+
+func (parser *Parser) parseThrowStatement() *ast.ThrowStatement {
+	statement := &ast.ThrowStatement{Token: parser.currentToken}
+
+	if parser.peekEndsExpression() {
+		parser.errors = append(parser.errors, "throw needs a value")
+
+		if parser.peekTokenIs(token.SEMICOLON) {
+			parser.nextToken()
+		}
+
+		return nil
+	}
+
 	parser.nextToken()
 
-	for !parser.currentTokenIs(token.SEMICOLON) {
+	statement.Value = parser.parserExpression(LOWEST)
+
+	if parser.peekTokenIs(token.SEMICOLON) {
+		parser.nextToken()
+	}
+
+	return statement
+}
+
+func (parser *Parser) parseTryStatement() *ast.TryStatement {
+	statement := &ast.TryStatement{Token: parser.currentToken}
+
+	if !parser.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	statement.Block = parser.parseBlockStatement()
+
+	if parser.peekTokenIs(token.CATCH) {
+		parser.nextToken()
+
+		if parser.peekTokenIs(token.LPAREN) {
+			parser.nextToken()
+
+			if !parser.expectPeek(token.IDENT) {
+				return nil
+			}
+
+			statement.CatchParameter = &ast.Identifier{Token: parser.currentToken, Value: parser.currentToken.Literal}
+
+			if !parser.expectPeek(token.RPAREN) {
+				return nil
+			}
+		}
+
+		if !parser.expectPeek(token.LBRACE) {
+			return nil
+		}
+
+		statement.Catch = parser.parseBlockStatement()
+	}
+
+	if parser.peekTokenIs(token.FINALLY) {
+		parser.nextToken()
+
+		if !parser.expectPeek(token.LBRACE) {
+			return nil
+		}
+
+		statement.Finally = parser.parseBlockStatement()
+	}
+
+	if statement.Catch == nil && statement.Finally == nil {
+		message := fmt.Sprintf("expected catch or finally after try block, got %s instead", parser.peekToken.Type)
+		parser.errors = append(parser.errors, message)
+
+		return nil
+	}
+
+	if parser.peekTokenIs(token.SEMICOLON) {
 		parser.nextToken()
 	}
 
@@ -167,6 +424,48 @@ func (parser *Parser) parseIntegerLiteral() ast.Expression {
 	return lit
 }
 
+func (parser *Parser) parseStringLiteral() ast.Expression {
+	return &ast.StringLiteral{Token: parser.currentToken, Value: parser.currentToken.Literal}
+}
+
+func (parser *Parser) parseTemplateLiteral() ast.Expression {
+	template := &ast.TemplateLiteral{Token: parser.currentToken}
+	template.Parts = []ast.Expression{}
+
+	for {
+		parser.nextToken()
+
+		switch parser.currentToken.Type {
+		case token.TEMPLATE_STRING:
+			text := &ast.TemplateString{Token: parser.currentToken, Value: parser.currentToken.Literal}
+			template.Parts = append(template.Parts, text)
+
+		case token.TEMPLATE_EXPR_START:
+			parser.nextToken()
+
+			expression := parser.parserExpression(LOWEST)
+
+			if expression == nil || !parser.expectPeek(token.TEMPLATE_EXPR_END) {
+				return nil
+			}
+
+			template.Parts = append(template.Parts, expression)
+
+		case token.TEMPLATE_END:
+			return template
+
+		default:
+			// the lexer has already reported unterminated templates
+			if !parser.currentTokenIs(token.ILLEGAL) {
+				message := fmt.Sprintf("unexpected %s in template literal", parser.currentToken.Type)
+				parser.errors = append(parser.errors, message)
+			}
+
+			return nil
+		}
+	}
+}
+
 func (parser *Parser) parsePrefixExpression() ast.Expression {
 	expression := &ast.PrefixExpression{
 		Token:    parser.currentToken,
@@ -179,6 +478,279 @@ func (parser *Parser) parsePrefixExpression() ast.Expression {
 	return expression
 }
 
+func (parser *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
+	expression := &ast.InfixExpression{
+		Token:    parser.currentToken,
+		Operator: parser.currentToken.Literal,
+		Left:     left,
+	}
+
+	precedence := parser.currentPrecedence()
+	parser.nextToken()
+	expression.Right = parser.parserExpression(precedence)
+
+	return expression
+}
+
+func (parser *Parser) parseGroupedExpression() ast.Expression {
+	if parser.isArrowParameterList() {
+		start := parser.currentToken
+		parameters := parser.parseFunctionParameters()
+
+		if parameters == nil {
+			return nil
+		}
+
+		return parser.parseArrowFunction(start, parameters)
+	}
+
+	parser.nextToken()
+
+	expression := parser.parserExpression(LOWEST)
+
+	if !parser.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	parser.group = expression
+
+	return expression
+}
+
+func (parser *Parser) parseIfExpression() ast.Expression {
+	expression := &ast.IfExpression{Token: parser.currentToken}
+
+	if !parser.expectPeek(token.LPAREN) {
+		return nil
+	}
+
+	parser.nextToken()
+	expression.Condition = parser.parserExpression(LOWEST)
+
+	if expression.Condition == nil || !parser.expectPeek(token.RPAREN) || !parser.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	expression.Consequence = parser.parseBlockStatement()
+
+	if !parser.peekTokenIs(token.ELSE) {
+		return expression
+	}
+
+	parser.nextToken()
+
+	// else if: the nested if becomes the only statement of the alternative
+	if parser.peekTokenIs(token.IF) {
+		parser.nextToken()
+
+		alternative := &ast.BlockStatement{Token: parser.currentToken}
+		nested := parser.parseIfExpression()
+
+		if nested == nil {
+			return nil
+		}
+
+		alternative.Statements = []ast.Statement{&ast.ExpressionStatement{Token: alternative.Token, Expression: nested}}
+		expression.Alternative = alternative
+
+		return expression
+	}
+
+	if !parser.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	expression.Alternative = parser.parseBlockStatement()
+
+	return expression
+}
+
+func (parser *Parser) parseMemberExpression(object ast.Expression) ast.Expression {
+	expression := &ast.MemberExpression{Token: parser.currentToken, Object: object}
+
+	if !parser.expectPeek(token.IDENT) {
+		return nil
+	}
+
+	expression.Property = &ast.Identifier{Token: parser.currentToken, Value: parser.currentToken.Literal}
+
+	return expression
+}
+
+// parseIndexExpression parses arr[index] as well as the slice forms
+// arr[low:high] and arr[low:high:step], where every part is optional.
+func (parser *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
+	bracket := parser.currentToken
+
+	parser.nextToken()
+
+	var low ast.Expression
+
+	if !parser.currentTokenIs(token.COLON) {
+		low = parser.parserExpression(LOWEST)
+
+		if parser.peekTokenIs(token.RBRACKET) {
+			parser.nextToken()
+
+			return &ast.IndexExpression{Token: bracket, Left: left, Index: low}
+		}
+
+		if !parser.expectPeek(token.COLON) {
+			return nil
+		}
+	}
+
+	slice := &ast.SliceExpression{Token: bracket, Left: left, Low: low}
+
+	if !parser.peekTokenIs(token.RBRACKET) && !parser.peekTokenIs(token.COLON) {
+		parser.nextToken()
+		slice.High = parser.parserExpression(LOWEST)
+	}
+
+	if parser.peekTokenIs(token.COLON) {
+		parser.nextToken()
+
+		if !parser.peekTokenIs(token.RBRACKET) {
+			parser.nextToken()
+			slice.Step = parser.parserExpression(LOWEST)
+		}
+	}
+
+	if !parser.expectPeek(token.RBRACKET) {
+		return nil
+	}
+
+	return slice
+}
+
+func (parser *Parser) parseBoolean() ast.Expression {
+	return &ast.Boolean{Token: parser.currentToken, Value: parser.currentTokenIs(token.TRUE)}
+}
+
+func (parser *Parser) parseBlockStatement() *ast.BlockStatement {
+	block := &ast.BlockStatement{Token: parser.currentToken}
+	block.Statements = []ast.Statement{}
+
+	parser.nextToken()
+
+	for !parser.currentTokenIs(token.RBRACE) && !parser.currentTokenIs(token.EOF) {
+		if parser.currentTokenIs(token.IMPORT) || parser.currentTokenIs(token.EXPORT) {
+			message := fmt.Sprintf("%s is only allowed at the top level of a module", parser.currentToken.Literal)
+			parser.errors = append(parser.errors, message)
+		}
+
+		statement := parser.parseStatement()
+
+		if statement != nil {
+			block.Statements = append(block.Statements, statement)
+		}
+
+		parser.nextToken()
+	}
+
+	return block
+}
+
+func (parser *Parser) parseFunctionLiteral() ast.Expression {
+	literal := &ast.FunctionLiteral{Token: parser.currentToken}
+
+	if parser.peekTokenIs(token.ASTERISK) {
+		parser.nextToken()
+		literal.IsGenerator = true
+	}
+
+	if !parser.expectPeek(token.LPAREN) {
+		return nil
+	}
+
+	literal.Parameters = parser.parseFunctionParameters()
+
+	if literal.Parameters == nil {
+		return nil
+	}
+
+	if parser.peekTokenIs(token.THIN_ARROW) {
+		parser.nextToken()
+		parser.nextToken()
+
+		if literal.ReturnType = parser.parseType(); literal.ReturnType == nil {
+			return nil
+		}
+	}
+
+	if !parser.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	parser.enterFunction(literal)
+	literal.Body = parser.parseBlockStatement()
+	parser.leaveFunction()
+
+	return literal
+}
+
+func (parser *Parser) parseArrayLiteral() ast.Expression {
+	array := &ast.ArrayLiteral{Token: parser.currentToken}
+
+	array.Elements = parser.parseExpressionList(token.RBRACKET)
+
+	return array
+}
+
+func (parser *Parser) parseExpressionList(end token.TokenType) []ast.Expression {
+	list := []ast.Expression{}
+
+	if parser.peekTokenIs(end) {
+		parser.nextToken()
+
+		return list
+	}
+
+	parser.nextToken()
+	list = append(list, parser.parserExpression(LOWEST))
+
+	for parser.peekTokenIs(token.COMMA) {
+		parser.nextToken()
+		parser.nextToken()
+		list = append(list, parser.parserExpression(LOWEST))
+	}
+
+	if !parser.expectPeek(end) {
+		return nil
+	}
+
+	return list
+}
+
+func (parser *Parser) parseHashLiteral() ast.Expression {
+	hash := &ast.HashLiteral{Token: parser.currentToken}
+	hash.Pairs = []*ast.HashPair{}
+
+	for !parser.peekTokenIs(token.RBRACE) {
+		parser.nextToken()
+		key := parser.parserExpression(LOWEST)
+
+		if !parser.expectPeek(token.COLON) {
+			return nil
+		}
+
+		parser.nextToken()
+		value := parser.parserExpression(LOWEST)
+
+		hash.Pairs = append(hash.Pairs, &ast.HashPair{Key: key, Value: value})
+
+		if !parser.peekTokenIs(token.RBRACE) && !parser.expectPeek(token.COMMA) {
+			return nil
+		}
+	}
+
+	if !parser.expectPeek(token.RBRACE) {
+		return nil
+	}
+
+	return hash
+}
+
 func (parser *Parser) currentTokenIs(tokenType token.TokenType) bool {
 	return parser.currentToken.Type == tokenType
 }
@@ -187,6 +759,22 @@ func (parser *Parser) peekTokenIs(tokenType token.TokenType) bool {
 	return parser.peekToken.Type == tokenType
 }
 
+func (parser *Parser) peekPrecedence() int {
+	if precedence, ok := precedences[parser.peekToken.Type]; ok {
+		return precedence
+	}
+
+	return LOWEST
+}
+
+func (parser *Parser) currentPrecedence() int {
+	if precedence, ok := precedences[parser.currentToken.Type]; ok {
+		return precedence
+	}
+
+	return LOWEST
+}
+
 func (parser *Parser) peekError(tokenType token.TokenType) {
 	message := fmt.Sprintf("expected next token to be %s, got %s instead", tokenType, parser.peekToken.Type)
 
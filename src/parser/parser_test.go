@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"monkey/ast"
 	"monkey/lexer"
+	"monkey/token"
 	"testing"
 )
 
@@ -40,6 +41,24 @@ func TestLetStatements(t *testing.T) {
 	}
 }
 
+func TestConstStatements(t *testing.T) {
+	input := "const day = 60 * 60 * 24; const [a, b] = pair; let c = 1;"
+
+	parser := NewParser(lexer.NewLexer(input))
+	program := parser.ParseProgram()
+	checkParserErrors(t, parser, input)
+
+	if program.String() != "const day = ((60 * 60) * 24);const [a, b] = pair;let c = 1;" {
+		t.Errorf("program.String() wrong. got=%q", program.String())
+	}
+
+	for i, expected := range []bool{true, true, false} {
+		if statement := program.Statemens[i].(*ast.LetStatement); statement.IsConst() != expected {
+			t.Errorf("statement %d IsConst() = %t, expected %t", i, statement.IsConst(), expected)
+		}
+	}
+}
+
 func TestReturnStatements(t *testing.T) {
 	input := `return 5; return 10; return 993322;`
 
@@ -67,6 +86,28 @@ func TestReturnStatements(t *testing.T) {
 	}
 }
 
+func TestBareReturnStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"return;", "return ;"},
+		{"let f = fn() { return }; f;", "let f = fn() return ;;f"},
+		{"let g = fn(x) { if (x) { return; } x };", "let g = fn(x) ifx return ;x;"},
+	}
+
+	for _, tt := range tests {
+		lexer := lexer.NewLexer(tt.input)
+		parser := NewParser(lexer)
+		program := parser.ParseProgram()
+		checkParserErrors(t, parser, tt.input)
+
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+}
+
 func testLetStatement(t *testing.T, statement ast.Statement, name string) bool {
 	if statement.TokenLiteral() != "let" {
 		t.Errorf("statement.TokenLiteral() not 'let'. got=%q", statement.TokenLiteral())
@@ -238,3 +279,1388 @@ func checkParserErrors(t *testing.T, parser *Parser, from string) {
 
 	t.FailNow()
 }
+
+func TestLetDestructuringPatterns(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let [a, b] = arr;", "let [a, b] = arr;"},
+		{"let [a, b, ...rest] = arr;", "let [a, b, ...rest] = arr;"},
+		{"let [] = arr;", "let [] = arr;"},
+		{"let [[a, b], {c}] = arr;", "let [[a, b], {c}] = arr;"},
+		{"let {name, age: years} = person;", "let {name, age: years} = person;"},
+		{"let {name, ...others} = person;", "let {name, ...others} = person;"},
+		{"let {pos: [x, y]} = point;", "let {pos: [x, y]} = point;"},
+		{"let [a, ...rest] = [1, 2, 3];", "let [a, ...rest] = [1, 2, 3];"},
+	}
+
+	for _, tt := range tests {
+		lexer := lexer.NewLexer(tt.input)
+		parser := NewParser(lexer)
+		program := parser.ParseProgram()
+		checkParserErrors(t, parser, tt.input)
+
+		if len(program.Statemens) != 1 {
+			t.Fatalf("program.Statemens does not contain 1 statement. got=%d", len(program.Statemens))
+		}
+
+		statement, ok := program.Statemens[0].(*ast.LetStatement)
+
+		if !ok {
+			t.Fatalf("statement not *ast.LetStatement. got=%T", program.Statemens[0])
+		}
+
+		if statement.Pattern == nil || statement.Name != nil {
+			t.Errorf("expected a destructuring pattern for %q. got Name=%v", tt.input, statement.Name)
+		}
+
+		if program.String() != tt.expected {
+			t.Errorf("program.String() wrong. expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+}
+
+func TestHashPatternPairs(t *testing.T) {
+	lexer := lexer.NewLexer("let {name, age: years} = person;")
+	parser := NewParser(lexer)
+	program := parser.ParseProgram()
+	checkParserErrors(t, parser, "hash pattern")
+
+	statement := program.Statemens[0].(*ast.LetStatement)
+	pattern, ok := statement.Pattern.(*ast.HashPattern)
+
+	if !ok {
+		t.Fatalf("statement.Pattern not *ast.HashPattern. got=%T", statement.Pattern)
+	}
+
+	tests := []struct{ key, binding string }{{"name", "name"}, {"age", "years"}}
+
+	if len(pattern.Pairs) != len(tests) {
+		t.Fatalf("pattern.Pairs has wrong length. got=%d", len(pattern.Pairs))
+	}
+
+	for i, tt := range tests {
+		pair := pattern.Pairs[i]
+
+		if pair.Key.Value != tt.key {
+			t.Errorf("pair[%d].Key not %q. got=%q", i, tt.key, pair.Key.Value)
+		}
+
+		if pair.Value.String() != tt.binding {
+			t.Errorf("pair[%d].Value not %q. got=%q", i, tt.binding, pair.Value.String())
+		}
+	}
+}
+
+func TestDestructuringErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let [a, b] = [1, 2, 3];", "destructuring mismatch: cannot bind [a, b] to [1, 2, 3]: expected 2 elements, got 3"},
+		{"let [a, b, ...c] = [1];", "destructuring mismatch: cannot bind [a, b, ...c] to [1]: expected at least 2 elements, got 1"},
+		{"let [a, [b]] = [1, 2];", "destructuring mismatch: cannot bind [b] to 2: value is not an array"},
+		{"let {a} = [1];", "destructuring mismatch: cannot bind {a} to [1]: value is not a hash"},
+		{"let [...rest, a] = arr;", "rest element ...rest must be the last element of array pattern"},
+		{"let {5} = person;", "expected field name in hash pattern, got INT instead"},
+		{"let [5] = arr;", "expected binding pattern, got INT instead"},
+	}
+
+	for _, tt := range tests {
+		lexer := lexer.NewLexer(tt.input)
+		parser := NewParser(lexer)
+		parser.ParseProgram()
+
+		errors := parser.Errors()
+
+		if len(errors) == 0 {
+			t.Errorf("expected parser error for %q, got none", tt.input)
+			continue
+		}
+
+		if errors[0] != tt.expected {
+			t.Errorf("wrong error for %q. expected=%q, got=%q", tt.input, tt.expected, errors[0])
+		}
+	}
+}
+
+func TestFunctionParameterPatterns(t *testing.T) {
+	input := "fn(x, [first, ...rest], {name}) { x; };"
+
+	lexer := lexer.NewLexer(input)
+	parser := NewParser(lexer)
+	program := parser.ParseProgram()
+	checkParserErrors(t, parser, "function parameter patterns")
+
+	statement := program.Statemens[0].(*ast.ExpressionStatement)
+	function, ok := statement.Expression.(*ast.FunctionLiteral)
+
+	if !ok {
+		t.Fatalf("statement.Expression not *ast.FunctionLiteral. got=%T", statement.Expression)
+	}
+
+	expected := []string{"x", "[first, ...rest]", "{name}"}
+
+	if len(function.Parameters) != len(expected) {
+		t.Fatalf("function.Parameters wrong. want %d, got=%d", len(expected), len(function.Parameters))
+	}
+
+	for i, parameter := range function.Parameters {
+		if parameter.String() != expected[i] {
+			t.Errorf("parameter[%d] wrong. expected=%q, got=%q", i, expected[i], parameter.String())
+		}
+	}
+
+	if len(function.Body.Statements) != 1 {
+		t.Errorf("function.Body.Statements has not 1 statement. got=%d", len(function.Body.Statements))
+	}
+}
+
+func TestOperatorPrecedenceParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"-a * b", "((-a) * b)"},
+		{"!-a", "(!(-a))"},
+		{"a + b - c", "((a + b) - c)"},
+		{"a * b / c", "((a * b) / c)"},
+		{"a + b * c + d / e - f", "(((a + (b * c)) + (d / e)) - f)"},
+		{"5 > 4 == 3 < 4", "((5 > 4) == (3 < 4))"},
+		{"3 + 4 * 5 == 3 * 1 + 4 * 5", "((3 + (4 * 5)) == ((3 * 1) + (4 * 5)))"},
+		{"true != false", "(true != false)"},
+		{"a + add(b * c) + d", "((a + add((b * c))) + d)"},
+		{"add(a, b, 1, 2 * 3, 4 + 5, add(6, 7 * 8))", "add(a, b, 1, (2 * 3), (4 + 5), add(6, (7 * 8)))"},
+	}
+
+	for _, tt := range tests {
+		lexer := lexer.NewLexer(tt.input)
+		parser := NewParser(lexer)
+		program := parser.ParseProgram()
+		checkParserErrors(t, parser, tt.input)
+
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+}
+
+func TestFunctionParameters(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"fn() {};", []string{}},
+		{"fn(x, y = 10) {};", []string{"x", "y = 10"}},
+		{"fn(first, ...others) {};", []string{"first", "...others"}},
+		{"fn(a, b = 1 + 2, verbose = false, ...rest) {};", []string{"a", "b = (1 + 2)", "verbose = false", "...rest"}},
+		{"fn({name, age} = person) {};", []string{"{name, age} = person"}},
+	}
+
+	for _, tt := range tests {
+		lexer := lexer.NewLexer(tt.input)
+		parser := NewParser(lexer)
+		program := parser.ParseProgram()
+		checkParserErrors(t, parser, tt.input)
+
+		statement := program.Statemens[0].(*ast.ExpressionStatement)
+		function, ok := statement.Expression.(*ast.FunctionLiteral)
+
+		if !ok {
+			t.Fatalf("statement.Expression not *ast.FunctionLiteral. got=%T", statement.Expression)
+		}
+
+		if len(function.Parameters) != len(tt.expected) {
+			t.Fatalf("length parameters wrong. want %d, got=%d", len(tt.expected), len(function.Parameters))
+		}
+
+		for i, parameter := range function.Parameters {
+			if parameter.String() != tt.expected[i] {
+				t.Errorf("parameter[%d] wrong. expected=%q, got=%q", i, tt.expected[i], parameter.String())
+			}
+		}
+	}
+}
+
+func TestCallExpressionArguments(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"f(...args);", "f(...args)"},
+		{"f(1, ...rest, 2);", "f(1, ...rest, 2)"},
+		{"f(y: 2);", "f(y: 2)"},
+		{"f(1, x: 1 + 2, y: g(3));", "f(1, x: (1 + 2), y: g(3))"},
+	}
+
+	for _, tt := range tests {
+		lexer := lexer.NewLexer(tt.input)
+		parser := NewParser(lexer)
+		program := parser.ParseProgram()
+		checkParserErrors(t, parser, tt.input)
+
+		statement := program.Statemens[0].(*ast.ExpressionStatement)
+
+		if _, ok := statement.Expression.(*ast.CallExpression); !ok {
+			t.Fatalf("statement.Expression not *ast.CallExpression. got=%T", statement.Expression)
+		}
+
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+
+	program := NewParser(lexer.NewLexer("f(1, y: 2);")).ParseProgram()
+	call := program.Statemens[0].(*ast.ExpressionStatement).Expression.(*ast.CallExpression)
+	named, ok := call.Arguments[1].(*ast.NamedArgument)
+
+	if !ok {
+		t.Fatalf("call.Arguments[1] not *ast.NamedArgument. got=%T", call.Arguments[1])
+	}
+
+	if named.Name.Value != "y" || !testIntegerLiteral(t, named.Value, 2) {
+		t.Errorf("named argument wrong. got=%s", named.String())
+	}
+}
+
+func TestFunctionParameterErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"fn(x, x) {};", "duplicate parameter name x"},
+		{"fn(x, [y, x]) {};", "duplicate parameter name x"},
+		{"fn(x, ...x) {};", "duplicate parameter name x"},
+		{"fn(x = 1, y) {};", "required parameter y follows parameter with default value x = 1"},
+		{"fn(...rest, x) {};", "rest parameter ...rest must be the last parameter"},
+		{"fn(...rest = []) {};", "rest parameter ...rest cannot have a default value"},
+		{"f(x: 1, 2);", "positional argument 2 follows named argument"},
+		{"f(x: 1, x: 2);", "duplicate named argument x"},
+	}
+
+	for _, tt := range tests {
+		lexer := lexer.NewLexer(tt.input)
+		parser := NewParser(lexer)
+		parser.ParseProgram()
+
+		errors := parser.Errors()
+
+		if len(errors) == 0 {
+			t.Errorf("expected parser error for %q, got none", tt.input)
+			continue
+		}
+
+		if errors[0] != tt.expected {
+			t.Errorf("wrong error for %q. expected=%q, got=%q", tt.input, tt.expected, errors[0])
+		}
+	}
+}
+
+func TestGroupedExpressionParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"1 + (2 + 3) + 4", "((1 + (2 + 3)) + 4)"},
+		{"(5 + 5) * 2", "((5 + 5) * 2)"},
+		{"-(5 + 5)", "(-(5 + 5))"},
+		{"add((a), (b + c))", "add(a, (b + c))"},
+	}
+
+	for _, tt := range tests {
+		lexer := lexer.NewLexer(tt.input)
+		parser := NewParser(lexer)
+		program := parser.ParseProgram()
+		checkParserErrors(t, parser, tt.input)
+
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+}
+
+func TestIfExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"if (x < y) { x }", "if(x < y) x"},
+		{"if (x) { x } else { y; z }", "ifx xelse yz"},
+		{"let v = if (a) { 1 } else if (b) { 2 } else { 3 };", "let v = ifa 1else ifb 2else 3;"},
+		{"if (a) { 1 } + 2", "(ifa 1 + 2)"},
+	}
+
+	for _, tt := range tests {
+		lexer := lexer.NewLexer(tt.input)
+		parser := NewParser(lexer)
+		program := parser.ParseProgram()
+		checkParserErrors(t, parser, tt.input)
+
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+
+	program := NewParser(lexer.NewLexer("if (a) { 1 } else if (b) { 2 }")).ParseProgram()
+	expression := program.Statemens[0].(*ast.ExpressionStatement).Expression.(*ast.IfExpression)
+
+	if expression.Alternative == nil || len(expression.Alternative.Statements) != 1 {
+		t.Fatalf("expected else if to give an alternative with one statement. got=%v", expression.Alternative)
+	}
+
+	if expression.Alternative.Token.Type != token.IF {
+		t.Errorf("alternative token is not IF. got=%q", expression.Alternative.Token.Type)
+	}
+
+	nested := expression.Alternative.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.IfExpression)
+
+	if nested.Alternative != nil {
+		t.Errorf("expected nested if without else. got=%v", nested.Alternative)
+	}
+
+	for input, expected := range map[string]string{
+		"if x { 1 }":        "expected next token to be (, got IDENT instead",
+		"if (x) 1":          "expected next token to be {, got INT instead",
+		"if (x) { 1 } else": "expected next token to be {, got EOF instead",
+	} {
+		parser := NewParser(lexer.NewLexer(input))
+		parser.ParseProgram()
+
+		if errors := parser.Errors(); len(errors) == 0 || errors[0] != expected {
+			t.Errorf("wrong errors for %q. expected=%q, got=%q", input, expected, errors)
+		}
+	}
+}
+
+func TestArrowFunctionParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"x => x * 2", "fn(x) (x * 2)"},
+		{"(a, b) => a + b", "fn(a, b) (a + b)"},
+		{"() => 1", "fn() 1"},
+		{"(x) => x", "fn(x) x"},
+		{"(x, y = 1, ...rest) => x", "fn(x, y = 1, ...rest) x"},
+		{"([a, b]) => a", "fn([a, b]) a"},
+		{"(x) => { return x; }", "fn(x) return x;"},
+		{"map(xs, x => x * 2)", "map(xs, fn(x) (x * 2))"},
+		{"map(xs, (x) => (x + 1) * 2)", "map(xs, fn(x) ((x + 1) * 2))"},
+		{"filter(xs, (x) => x > 1, 3)", "filter(xs, fn(x) (x > 1), 3)"},
+		{"(f(x)) + 1", "(f(x) + 1)"},
+		{"({a, b: [c]}, x = (1 + 2)) => x", "fn({a, b: [c]}, x = (1 + 2)) x"},
+		{"(f: fn(int) -> int, ...xs: [int]) => f", "fn(f: fn(int) -> int, ...xs: [int]) f"},
+		{"(fn(x) { x })(1)", "fn(x) x(1)"},
+		{"([a, b] + [c])", "([a, b] + [c])"},
+		{"((((x))))", "x"},
+	}
+
+	for _, tt := range tests {
+		lexer := lexer.NewLexer(tt.input)
+		parser := NewParser(lexer)
+		program := parser.ParseProgram()
+		checkParserErrors(t, parser, tt.input)
+
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+
+	program := NewParser(lexer.NewLexer("x => x;")).ParseProgram()
+	statement := program.Statemens[0].(*ast.ExpressionStatement)
+	function, ok := statement.Expression.(*ast.FunctionLiteral)
+
+	if !ok {
+		t.Fatalf("statement.Expression not *ast.FunctionLiteral. got=%T", statement.Expression)
+	}
+
+	if !function.IsArrow {
+		t.Errorf("function.IsArrow not set for arrow function")
+	}
+
+	if len(function.Body.Statements) != 1 {
+		t.Fatalf("function.Body.Statements has not 1 statement. got=%d", len(function.Body.Statements))
+	}
+
+	if _, ok := function.Body.Statements[0].(*ast.ExpressionStatement); !ok {
+		t.Errorf("function body is not ast.ExpressionStatement. got=%T", function.Body.Statements[0])
+	}
+
+	for input, expected := range map[string][2]int{
+		"x => x;":                 {1, 1},
+		"let f =\n  (a, b) => a;": {2, 3},
+		"map(xs, () => 1);":       {1, 9},
+	} {
+		var function *ast.FunctionLiteral
+
+		ast.Inspect(NewParser(lexer.NewLexer(input)).ParseProgram(), func(node ast.Node) bool {
+			if literal, ok := node.(*ast.FunctionLiteral); ok {
+				function = literal
+			}
+
+			return true
+		})
+
+		if function == nil || function.Token.Line != expected[0] || function.Token.Column != expected[1] {
+			t.Errorf("wrong position of the arrow function in %q. expected %d:%d, got=%+v", input, expected[0], expected[1], function)
+		}
+	}
+}
+
+func TestPipelineParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"xs |> sum", "(xs |> sum)"},
+		{"xs |> sum()", "(xs |> sum())"},
+		{"xs |> map(f) |> filter(g) |> sum", "(((xs |> map(f)) |> filter(g)) |> sum)"},
+		{"a + b |> f", "((a + b) |> f)"},
+		{"xs |> map(x => x * 2)", "(xs |> map(fn(x) (x * 2)))"},
+		{"x |> (y |> g)", "(x |> (y |> g))"},
+		{"f(xs |> sum, 1)", "f((xs |> sum), 1)"},
+		{"x |> (f(y))", "(x |> (f(y)))"},
+		{"x |> (f)(y)", "(x |> f(y))"},
+	}
+
+	for _, tt := range tests {
+		lexer := lexer.NewLexer(tt.input)
+		parser := NewParser(lexer)
+		program := parser.ParseProgram()
+		checkParserErrors(t, parser, tt.input)
+
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+}
+
+func TestPipelineDesugaring(t *testing.T) {
+	input := "xs |> map(f) |> filter(g) |> sum;"
+
+	lexer := lexer.NewLexer(input)
+	parser := NewParser(lexer)
+	program := parser.ParseProgram()
+	checkParserErrors(t, parser, input)
+
+	statement := program.Statemens[0].(*ast.ExpressionStatement)
+
+	tests := []struct {
+		function  string
+		arguments int
+	}{{"sum", 1}, {"filter", 2}, {"map", 2}}
+
+	expression := statement.Expression
+
+	for _, tt := range tests {
+		call, ok := expression.(*ast.CallExpression)
+
+		if !ok {
+			t.Fatalf("expression not *ast.CallExpression. got=%T", expression)
+		}
+
+		if call.Function.String() != tt.function {
+			t.Errorf("call.Function not %q. got=%q", tt.function, call.Function.String())
+		}
+
+		if len(call.Arguments) != tt.arguments {
+			t.Fatalf("wrong number of arguments for %s. want %d, got=%d", tt.function, tt.arguments, len(call.Arguments))
+		}
+
+		expression = call.Arguments[0]
+	}
+
+	if expression.String() != "xs" {
+		t.Errorf("innermost argument not xs. got=%q", expression.String())
+	}
+}
+
+func TestStringLiteralExpression(t *testing.T) {
+	input := `"hello world";`
+
+	lexer := lexer.NewLexer(input)
+	parser := NewParser(lexer)
+	program := parser.ParseProgram()
+	checkParserErrors(t, parser, input)
+
+	statement := program.Statemens[0].(*ast.ExpressionStatement)
+	literal, ok := statement.Expression.(*ast.StringLiteral)
+
+	if !ok {
+		t.Fatalf("exp not *ast.StringLiteral. got=%T", statement.Expression)
+	}
+
+	if literal.Value != "hello world" {
+		t.Errorf("literal.Value not %q. got=%q", "hello world", literal.Value)
+	}
+}
+
+func TestMemberExpressionParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"obj.field", "obj.field"},
+		{"a.b.c", "a.b.c"},
+		{"obj.method(1, 2)", "obj.method(1, 2)"},
+		{"-obj.value", "(-obj.value)"},
+		{"a.x + b.y * 2", "(a.x + (b.y * 2))"},
+		{`"abc".upper()`, "abc.upper()"},
+		{"[1, 2].len()", "[1, 2].len()"},
+		{"f(x).field.method()", "f(x).field.method()"},
+		{"xs |> obj.process", "(xs |> obj.process)"},
+	}
+
+	for _, tt := range tests {
+		lexer := lexer.NewLexer(tt.input)
+		parser := NewParser(lexer)
+		program := parser.ParseProgram()
+		checkParserErrors(t, parser, tt.input)
+
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+
+	program := NewParser(lexer.NewLexer("obj.method(1);")).ParseProgram()
+	call, ok := program.Statemens[0].(*ast.ExpressionStatement).Expression.(*ast.CallExpression)
+
+	if !ok {
+		t.Fatalf("expression not *ast.CallExpression. got=%T", program.Statemens[0])
+	}
+
+	member, ok := call.Function.(*ast.MemberExpression)
+
+	if !ok {
+		t.Fatalf("call.Function not *ast.MemberExpression. got=%T", call.Function)
+	}
+
+	if member.Object.String() != "obj" || member.Property.Value != "method" {
+		t.Errorf("member expression wrong. got=%s", member.String())
+	}
+}
+
+func TestIndexAndSliceExpressionParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"arr[1]", "(arr[1])"},
+		{"arr[-1]", "(arr[(-1)])"},
+		{"arr[1 + 1]", "(arr[(1 + 1)])"},
+		{"a * [1, 2, 3, 4][b * c] * d", "((a * ([1, 2, 3, 4][(b * c)])) * d)"},
+		{"add(a * b[2], b[1], 2 * [1, 2][1])", "add((a * (b[2])), (b[1]), (2 * ([1, 2][1])))"},
+		{"s[1:4]", "(s[1:4])"},
+		{"arr[:n]", "(arr[:n])"},
+		{"arr[n:]", "(arr[n:])"},
+		{"arr[:]", "(arr[:])"},
+		{"arr[::2]", "(arr[::2])"},
+		{"arr[1:-1:2]", "(arr[1:(-1):2])"},
+		{"arr[::]", "(arr[:])"},
+		{"matrix[i][j]", "((matrix[i])[j])"},
+		{"obj.items[0]", "(obj.items[0])"},
+	}
+
+	for _, tt := range tests {
+		lexer := lexer.NewLexer(tt.input)
+		parser := NewParser(lexer)
+		program := parser.ParseProgram()
+		checkParserErrors(t, parser, tt.input)
+
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+}
+
+func TestSliceExpressionParts(t *testing.T) {
+	tests := []struct {
+		input string
+		low   string
+		high  string
+		step  string
+	}{
+		{"s[1:4];", "1", "4", ""},
+		{"s[:n];", "", "n", ""},
+		{"s[::2];", "", "", "2"},
+		{"s[a:b:c];", "a", "b", "c"},
+	}
+
+	part := func(expression ast.Expression) string {
+		if expression == nil {
+			return ""
+		}
+
+		return expression.String()
+	}
+
+	for _, tt := range tests {
+		lexer := lexer.NewLexer(tt.input)
+		parser := NewParser(lexer)
+		program := parser.ParseProgram()
+		checkParserErrors(t, parser, tt.input)
+
+		statement := program.Statemens[0].(*ast.ExpressionStatement)
+		slice, ok := statement.Expression.(*ast.SliceExpression)
+
+		if !ok {
+			t.Fatalf("exp not *ast.SliceExpression. got=%T", statement.Expression)
+		}
+
+		if part(slice.Low) != tt.low || part(slice.High) != tt.high || part(slice.Step) != tt.step {
+			t.Errorf("slice parts wrong for %q. got low=%q high=%q step=%q",
+				tt.input, part(slice.Low), part(slice.High), part(slice.Step))
+		}
+	}
+}
+
+func TestTemplateLiteralParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"`plain`", "`plain`"},
+		{"``", "``"},
+		{"`Hello ${user.name}, you have ${n} items`", "`Hello ${user.name}, you have ${n} items`"},
+		{"`sum: ${a + b * 2}`", "`sum: ${(a + (b * 2))}`"},
+		{"`${ {a: 1}.a }`", "`${{a: 1}.a}`"},
+		{"`outer ${ `inner ${x}` } done`", "`outer ${`inner ${x}`} done`"},
+		{"`tick \\` and \\${x}`", "`tick \\` and \\${x}`"},
+	}
+
+	for _, tt := range tests {
+		lexer := lexer.NewLexer(tt.input)
+		parser := NewParser(lexer)
+		program := parser.ParseProgram()
+		checkParserErrors(t, parser, tt.input)
+
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+}
+
+func TestTemplateLiteralParts(t *testing.T) {
+	input := "`Hello ${name}!`;"
+
+	lexer := lexer.NewLexer(input)
+	parser := NewParser(lexer)
+	program := parser.ParseProgram()
+	checkParserErrors(t, parser, input)
+
+	statement := program.Statemens[0].(*ast.ExpressionStatement)
+	template, ok := statement.Expression.(*ast.TemplateLiteral)
+
+	if !ok {
+		t.Fatalf("exp not *ast.TemplateLiteral. got=%T", statement.Expression)
+	}
+
+	if len(template.Parts) != 3 {
+		t.Fatalf("template.Parts has not 3 parts. got=%d", len(template.Parts))
+	}
+
+	if text, ok := template.Parts[0].(*ast.TemplateString); !ok || text.Value != "Hello " {
+		t.Errorf("template.Parts[0] wrong. got=%#v", template.Parts[0])
+	}
+
+	if ident, ok := template.Parts[1].(*ast.Identifier); !ok || ident.Value != "name" {
+		t.Errorf("template.Parts[1] wrong. got=%#v", template.Parts[1])
+	}
+
+	if text, ok := template.Parts[2].(*ast.TemplateString); !ok || text.Value != "!" {
+		t.Errorf("template.Parts[2] wrong. got=%#v", template.Parts[2])
+	}
+}
+
+func TestUnterminatedTemplateParserError(t *testing.T) {
+	lexer := lexer.NewLexer("let greeting = `Hi ${name")
+	parser := NewParser(lexer)
+	parser.ParseProgram()
+
+	errors := parser.Errors()
+
+	if len(errors) == 0 {
+		t.Fatalf("expected parser errors, got none")
+	}
+
+	if errors[0] != "1:20: unterminated ${ in template literal" {
+		t.Errorf("wrong first error. got=%q", errors[0])
+	}
+}
+
+func TestImportExportStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`import "lib/strings" as str;`, `import "lib/strings" as str;`},
+		{`import "helpers";`, `import "helpers";`},
+		{`export let x = 5;`, `export let x = 5;`},
+		{`export let {a, b} = pair;`, `export let {a, b} = pair;`},
+		{`export const x = 5;`, `export const x = 5;`},
+	}
+
+	for _, tt := range tests {
+		lexer := lexer.NewLexer(tt.input)
+		parser := NewParser(lexer)
+		program := parser.ParseProgram()
+		checkParserErrors(t, parser, tt.input)
+
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+
+	program := NewParser(lexer.NewLexer(`import "lib/strings" as str;`)).ParseProgram()
+	statement, ok := program.Statemens[0].(*ast.ImportStatement)
+
+	if !ok {
+		t.Fatalf("statement not *ast.ImportStatement. got=%T", program.Statemens[0])
+	}
+
+	if statement.Path.Value != "lib/strings" || statement.Alias.Value != "str" {
+		t.Errorf("import statement wrong. got path=%q alias=%q", statement.Path.Value, statement.Alias.Value)
+	}
+}
+
+func TestImportExportErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`import lib;`, "expected next token to be STRING, got IDENT instead"},
+		{`import "lib" as 5;`, "expected next token to be IDENT, got INT instead"},
+		{`export 5;`, "expected next token to be LET, got INT instead"},
+		{`fn() { export let x = 1; };`, "export is only allowed at the top level of a module"},
+	}
+
+	for _, tt := range tests {
+		lexer := lexer.NewLexer(tt.input)
+		parser := NewParser(lexer)
+		parser.ParseProgram()
+
+		errors := parser.Errors()
+
+		if len(errors) == 0 || errors[0] != tt.expected {
+			t.Errorf("wrong errors for %q. expected=%q, got=%q", tt.input, tt.expected, errors)
+		}
+	}
+}
+
+func TestThrowStatement(t *testing.T) {
+	input := `throw "record " + id;`
+
+	lexer := lexer.NewLexer(input)
+	parser := NewParser(lexer)
+	program := parser.ParseProgram()
+	checkParserErrors(t, parser, input)
+
+	statement, ok := program.Statemens[0].(*ast.ThrowStatement)
+
+	if !ok {
+		t.Fatalf("statement not *ast.ThrowStatement. got=%T", program.Statemens[0])
+	}
+
+	if statement.Value.String() != "(record  + id)" {
+		t.Errorf("statement.Value wrong. got=%q", statement.Value.String())
+	}
+}
+
+func TestTryStatement(t *testing.T) {
+	tests := []struct {
+		input      string
+		expected   string
+		hasCatch   bool
+		parameter  string
+		hasFinally bool
+	}{
+		{"try { f(); } catch (e) { log(e); }", "try f() catch (e) log(e)", true, "e", false},
+		{"try { f(); } finally { close(); }", "try f() finally close()", false, "", true},
+		{"try { f(); } catch { g(); } finally { close(); }", "try f() catch g() finally close()", true, "", true},
+		{"try { throw 1; } catch (err) { } finally { }", "try throw 1; catch (err)  finally ", true, "err", true},
+		{"try { 1 } catch (e) { 2 };", "try 1 catch (e) 2", true, "e", false},
+	}
+
+	for _, tt := range tests {
+		lexer := lexer.NewLexer(tt.input)
+		parser := NewParser(lexer)
+		program := parser.ParseProgram()
+		checkParserErrors(t, parser, tt.input)
+
+		if len(program.Statemens) != 1 {
+			t.Fatalf("program.Statemens does not contain 1 statement. got=%d", len(program.Statemens))
+		}
+
+		statement, ok := program.Statemens[0].(*ast.TryStatement)
+
+		if !ok {
+			t.Fatalf("statement not *ast.TryStatement. got=%T", program.Statemens[0])
+		}
+
+		if (statement.Catch != nil) != tt.hasCatch || (statement.Finally != nil) != tt.hasFinally {
+			t.Errorf("clauses wrong for %q. catch=%v finally=%v", tt.input, statement.Catch != nil, statement.Finally != nil)
+		}
+
+		if tt.parameter != "" && (statement.CatchParameter == nil || statement.CatchParameter.Value != tt.parameter) {
+			t.Errorf("catch parameter wrong for %q. got=%v", tt.input, statement.CatchParameter)
+		}
+
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+}
+
+func TestTryStatementErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"try { f(); }", "expected catch or finally after try block, got EOF instead"},
+		{"try { f(); } catch (5) { }", "expected next token to be IDENT, got INT instead"},
+		{"try f();", "expected next token to be {, got IDENT instead"},
+		{"throw;", "throw needs a value"},
+		{"let f = fn() { throw };", "throw needs a value"},
+	}
+
+	for _, tt := range tests {
+		lexer := lexer.NewLexer(tt.input)
+		parser := NewParser(lexer)
+		parser.ParseProgram()
+
+		errors := parser.Errors()
+
+		if len(errors) == 0 || errors[0] != tt.expected {
+			t.Errorf("wrong errors for %q. expected=%q, got=%q", tt.input, tt.expected, errors)
+		}
+	}
+}
+
+func TestFailedStatementsAreSkipped(t *testing.T) {
+	lexer := lexer.NewLexer("let = 5; try { f(); } x;")
+	parser := NewParser(lexer)
+	program := parser.ParseProgram()
+
+	if len(parser.Errors()) == 0 {
+		t.Fatalf("expected parser errors, got none")
+	}
+
+	for i, statement := range program.Statemens {
+		if statement == nil {
+			t.Errorf("program.Statemens[%d] is nil", i)
+		}
+	}
+
+	// must not panic on failed statements
+	_ = program.String()
+}
+
+func TestDeferStatement(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"fn() { defer close(f); }", "fn() defer close(f);"},
+		{"fn() { defer unlock(); defer close(f); work(); }", "fn() defer unlock();defer close(f);work()"},
+		{"(f) => { defer close(f); }", "fn(f) defer close(f);"},
+		{"fn() { let g = fn() { 1 }; defer g(); }", "fn() let g = fn() 1;defer g();"},
+	}
+
+	for _, tt := range tests {
+		lexer := lexer.NewLexer(tt.input)
+		parser := NewParser(lexer)
+		program := parser.ParseProgram()
+		checkParserErrors(t, parser, tt.input)
+
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+
+	program := NewParser(lexer.NewLexer("fn() { defer close(f); }")).ParseProgram()
+	function := program.Statemens[0].(*ast.ExpressionStatement).Expression.(*ast.FunctionLiteral)
+	statement, ok := function.Body.Statements[0].(*ast.DeferStatement)
+
+	if !ok {
+		t.Fatalf("statement not *ast.DeferStatement. got=%T", function.Body.Statements[0])
+	}
+
+	if _, ok := statement.Call.(*ast.CallExpression); !ok {
+		t.Errorf("statement.Call not *ast.CallExpression. got=%T", statement.Call)
+	}
+}
+
+func TestDeferOutsideFunction(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"defer close(f);", ""},
+		{"let f = fn() { 1 }; defer f();", "let f = fn() 1;"},
+		{"try { defer close(f); } finally { }", "try  finally "},
+	}
+
+	for _, tt := range tests {
+		lexer := lexer.NewLexer(tt.input)
+		parser := NewParser(lexer)
+		program := parser.ParseProgram()
+
+		errors := parser.Errors()
+
+		if len(errors) != 1 || errors[0] != "defer is only allowed inside a function body" {
+			t.Errorf("wrong errors for %q. got=%q", tt.input, errors)
+		}
+
+		// the deferred call is skipped rather than run as a statement
+		if program.String() != tt.expected {
+			t.Errorf("wrong program for %q. expected=%q, got=%q", tt.input, tt.expected, program.String())
+		}
+	}
+}
+
+func TestDeferWithoutCall(t *testing.T) {
+	for _, input := range []string{"fn() { defer; }", "fn() { defer }", "defer;"} {
+		lexer := lexer.NewLexer(input)
+		parser := NewParser(lexer)
+		parser.ParseProgram()
+
+		errors := parser.Errors()
+
+		if len(errors) != 1 || errors[0] != "defer needs a call" {
+			t.Errorf("wrong errors for %q. got=%q", input, errors)
+		}
+	}
+}
+
+func TestGeneratorFunctions(t *testing.T) {
+	tests := []struct {
+		input       string
+		expected    string
+		isGenerator bool
+	}{
+		{"fn*() { 1 }", "fn*() 1", true},
+		{"fn() { yield 1; yield 2; }", "fn*() (yield 1)(yield 2)", true},
+		{"fn(n) { let x = yield; x }", "fn*(n) let x = (yield);x", true},
+		{"fn(xs) { f(yield xs[0], 2) }", "fn*(xs) f((yield (xs[0])), 2)", true},
+		{"fn() { yield a + b }", "fn*() (yield (a + b))", true},
+		{"(x) => { yield x; }", "fn*(x) (yield x)", true},
+		{"fn() { 1 }", "fn() 1", false},
+	}
+
+	for _, tt := range tests {
+		lexer := lexer.NewLexer(tt.input)
+		parser := NewParser(lexer)
+		program := parser.ParseProgram()
+		checkParserErrors(t, parser, tt.input)
+
+		function, ok := program.Statemens[0].(*ast.ExpressionStatement).Expression.(*ast.FunctionLiteral)
+
+		if !ok {
+			t.Fatalf("expression not *ast.FunctionLiteral for %q", tt.input)
+		}
+
+		if function.IsGenerator != tt.isGenerator {
+			t.Errorf("function.IsGenerator wrong for %q. got=%v", tt.input, function.IsGenerator)
+		}
+
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+}
+
+func TestYieldOnlyMarksInnermostFunction(t *testing.T) {
+	input := "fn() { fn() { yield 1 } }"
+
+	lexer := lexer.NewLexer(input)
+	parser := NewParser(lexer)
+	program := parser.ParseProgram()
+	checkParserErrors(t, parser, input)
+
+	outer := program.Statemens[0].(*ast.ExpressionStatement).Expression.(*ast.FunctionLiteral)
+	inner := outer.Body.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.FunctionLiteral)
+
+	if outer.IsGenerator {
+		t.Errorf("outer function should not be a generator")
+	}
+
+	if !inner.IsGenerator {
+		t.Errorf("inner function should be a generator")
+	}
+}
+
+func TestYieldOutsideFunction(t *testing.T) {
+	lexer := lexer.NewLexer("yield 1;")
+	parser := NewParser(lexer)
+	parser.ParseProgram()
+
+	errors := parser.Errors()
+
+	if len(errors) == 0 || errors[0] != "yield is only allowed inside a function body" {
+		t.Errorf("wrong errors. got=%q", errors)
+	}
+}
+
+func TestYieldInParameterDefault(t *testing.T) {
+	tests := []string{
+		"fn() { let g = fn(a = yield) { a }; }",
+		"fn(a = yield 1) { a }",
+		"fn() { (a = yield) => a }",
+	}
+
+	for _, input := range tests {
+		lexer := lexer.NewLexer(input)
+		parser := NewParser(lexer)
+		parser.ParseProgram()
+
+		errors := parser.Errors()
+
+		if len(errors) == 0 || errors[0] != "yield is not allowed in a parameter default" {
+			t.Errorf("wrong errors for %q. got=%q", input, errors)
+		}
+	}
+
+	// a function in a default value has a body of its own
+	input := "fn(next = fn() { yield 1 }) { next }"
+	program := NewParser(lexer.NewLexer(input)).ParseProgram()
+	outer := program.Statemens[0].(*ast.ExpressionStatement).Expression.(*ast.FunctionLiteral)
+	inner := outer.Parameters[0].Default.(*ast.FunctionLiteral)
+
+	if outer.IsGenerator || !inner.IsGenerator {
+		t.Errorf("wrong generators for %q. outer=%t inner=%t", input, outer.IsGenerator, inner.IsGenerator)
+	}
+}
+
+func TestSpawnExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"spawn fetch(url);", "(spawn fetch(url))"},
+		{"let task = spawn worker(ch, 1);", "let task = (spawn worker(ch, 1));"},
+		{"spawn client.get(url);", "(spawn client.get(url))"},
+		{"spawn fn() { send(ch, 1) }();", "(spawn fn() send(ch, 1)())"},
+	}
+
+	for _, tt := range tests {
+		lexer := lexer.NewLexer(tt.input)
+		parser := NewParser(lexer)
+		program := parser.ParseProgram()
+		checkParserErrors(t, parser, tt.input)
+
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+}
+
+func TestSelectStatement(t *testing.T) {
+	input := `select {
+	case msg = recv(inbox) { handle(msg); }
+	case send(out, result) { done(); }
+	default { idle(); }
+};`
+
+	lexer := lexer.NewLexer(input)
+	parser := NewParser(lexer)
+	program := parser.ParseProgram()
+	checkParserErrors(t, parser, "select")
+
+	if len(program.Statemens) != 1 {
+		t.Fatalf("program.Statemens does not contain 1 statement. got=%d", len(program.Statemens))
+	}
+
+	statement, ok := program.Statemens[0].(*ast.SelectStatement)
+
+	if !ok {
+		t.Fatalf("statement not *ast.SelectStatement. got=%T", program.Statemens[0])
+	}
+
+	if len(statement.Cases) != 2 {
+		t.Fatalf("statement.Cases has not 2 cases. got=%d", len(statement.Cases))
+	}
+
+	if statement.Cases[0].Binding == nil || statement.Cases[0].Binding.Value != "msg" {
+		t.Errorf("first case binding wrong. got=%v", statement.Cases[0].Binding)
+	}
+
+	if statement.Cases[1].Binding != nil {
+		t.Errorf("second case should not bind. got=%v", statement.Cases[1].Binding)
+	}
+
+	if statement.Default == nil {
+		t.Errorf("statement.Default is nil")
+	}
+
+	expected := "select { case msg = recv(inbox) handle(msg) case send(out, result) done() default idle() }"
+
+	if program.String() != expected {
+		t.Errorf("expected=%q, got=%q", expected, program.String())
+	}
+}
+
+func TestConcurrencyErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"spawn worker;", "spawn must be followed by a function call"},
+		{"select { }", "select needs at least one case"},
+		{"select { case f(x) { } }", "select case must be a send(...) or recv(...) call"},
+		{"select { case x = send(ch, 1) { } }", "cannot bind x to the result of send"},
+		{"select { case recv(ch) { } default { } default { } }", "multiple defaults in select"},
+		{"select { recv(ch) }", "expected case or default in select, got IDENT instead"},
+	}
+
+	for _, tt := range tests {
+		lexer := lexer.NewLexer(tt.input)
+		parser := NewParser(lexer)
+		parser.ParseProgram()
+
+		errors := parser.Errors()
+
+		if len(errors) == 0 || errors[0] != tt.expected {
+			t.Errorf("wrong errors for %q. expected=%q, got=%q", tt.input, tt.expected, errors)
+		}
+	}
+}
+
+func TestStructStatement(t *testing.T) {
+	tests := []struct {
+		input    string
+		name     string
+		fields   []string
+		expected string
+	}{
+		{"struct Point { x, y }", "Point", []string{"x", "y"}, "struct Point { x, y }"},
+		{"struct Empty {};", "Empty", []string{}, "struct Empty {}"},
+		{"struct User { name, age, };", "User", []string{"name", "age"}, "struct User { name, age }"},
+	}
+
+	for _, tt := range tests {
+		lexer := lexer.NewLexer(tt.input)
+		parser := NewParser(lexer)
+		program := parser.ParseProgram()
+		checkParserErrors(t, parser, tt.input)
+
+		if len(program.Statemens) != 1 {
+			t.Fatalf("program.Statemens does not contain 1 statement. got=%d", len(program.Statemens))
+		}
+
+		statement, ok := program.Statemens[0].(*ast.StructStatement)
+
+		if !ok {
+			t.Fatalf("statement not *ast.StructStatement. got=%T", program.Statemens[0])
+		}
+
+		if statement.Name.Value != tt.name {
+			t.Errorf("statement.Name wrong. expected=%s, got=%s", tt.name, statement.Name.Value)
+		}
+
+		if len(statement.Fields) != len(tt.fields) {
+			t.Fatalf("statement.Fields wrong. expected=%v, got=%v", tt.fields, statement.Fields)
+		}
+
+		for i, field := range statement.Fields {
+			if field.Value != tt.fields[i] {
+				t.Errorf("field[%d] wrong. expected=%s, got=%s", i, tt.fields[i], field.Value)
+			}
+		}
+
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+}
+
+func TestStructFieldAccess(t *testing.T) {
+	input := "struct Point { x, y } let p = Point(1, 2); p.x + p.y;"
+
+	lexer := lexer.NewLexer(input)
+	parser := NewParser(lexer)
+	program := parser.ParseProgram()
+	checkParserErrors(t, parser, input)
+
+	expected := "struct Point { x, y }let p = Point(1, 2);(p.x + p.y)"
+
+	if program.String() != expected {
+		t.Errorf("expected=%q, got=%q", expected, program.String())
+	}
+}
+
+func TestStructStatementErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"struct { x }", "expected next token to be IDENT, got { instead"},
+		{"struct Point { x, x }", "duplicate field x in Point"},
+		{"struct Point { x y }", "expected next token to be ,, got IDENT instead"},
+		{"struct Point { 1 }", "expected next token to be IDENT, got INT instead"},
+	}
+
+	for _, tt := range tests {
+		lexer := lexer.NewLexer(tt.input)
+		parser := NewParser(lexer)
+		parser.ParseProgram()
+
+		errors := parser.Errors()
+
+		if len(errors) == 0 || errors[0] != tt.expected {
+			t.Errorf("wrong errors for %q. expected=%q, got=%q", tt.input, tt.expected, errors)
+		}
+	}
+}
+
+func TestEnumStatement(t *testing.T) {
+	input := "enum Status { Pending, Done(result), Failed(err, code) }"
+
+	lexer := lexer.NewLexer(input)
+	parser := NewParser(lexer)
+	program := parser.ParseProgram()
+	checkParserErrors(t, parser, input)
+
+	statement, ok := program.Statemens[0].(*ast.EnumStatement)
+
+	if !ok {
+		t.Fatalf("statement not *ast.EnumStatement. got=%T", program.Statemens[0])
+	}
+
+	tests := []struct {
+		name   string
+		fields int
+	}{{"Pending", 0}, {"Done", 1}, {"Failed", 2}}
+
+	if len(statement.Variants) != len(tests) {
+		t.Fatalf("statement.Variants has not %d variants. got=%d", len(tests), len(statement.Variants))
+	}
+
+	for i, tt := range tests {
+		variant := statement.Variants[i]
+
+		if variant.Name.Value != tt.name || len(variant.Fields) != tt.fields {
+			t.Errorf("variant[%d] wrong. got=%s", i, variant.String())
+		}
+	}
+
+	if program.String() != input {
+		t.Errorf("expected=%q, got=%q", input, program.String())
+	}
+}
+
+func TestMatchExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"match s { Pending => 0, Done(r) => r, _ => 1 }", "match s { Pending => 0, Done(r) => r, _ => 1 }"},
+		{"match s { Status.Done(r) => { log(r); r }, }", "match s { Status.Done(r) => log(r)r }"},
+		{`match n { 1 => "one", true => x, other => other + 1 }`, "match n { 1 => one, true => x, other => (other + 1) }"},
+		{"match f(x) { Some([a, b]) => a + b, None => 0 }", "match f(x) { Some([a, b]) => (a + b), None => 0 }"},
+		{"let y = match x { Wrap(Inner(v)) => v, _ => 0 };", "let y = match x { Wrap(Inner(v)) => v, _ => 0 };"},
+	}
+
+	for _, tt := range tests {
+		lexer := lexer.NewLexer(tt.input)
+		parser := NewParser(lexer)
+		program := parser.ParseProgram()
+		checkParserErrors(t, parser, tt.input)
+
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+
+	program := NewParser(lexer.NewLexer("match s { Pending => 0, Done(r) => r, x => 1 }")).ParseProgram()
+	match := program.Statemens[0].(*ast.ExpressionStatement).Expression.(*ast.MatchExpression)
+
+	if _, ok := match.Arms[0].Pattern.(*ast.VariantPattern); !ok {
+		t.Errorf("arm[0] pattern not *ast.VariantPattern. got=%T", match.Arms[0].Pattern)
+	}
+
+	if variant, ok := match.Arms[1].Pattern.(*ast.VariantPattern); !ok || len(variant.Bindings) != 1 {
+		t.Errorf("arm[1] pattern wrong. got=%T", match.Arms[1].Pattern)
+	}
+
+	if _, ok := match.Arms[2].Pattern.(*ast.Identifier); !ok {
+		t.Errorf("arm[2] pattern not *ast.Identifier. got=%T", match.Arms[2].Pattern)
+	}
+}
+
+func TestEnumAndMatchErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"enum Status { }", "enum Status needs at least one variant"},
+		{"enum Status { A, A }", "duplicate variant A in enum Status"},
+		{"enum Status { A(x, x) }", "duplicate field x in Status.A"},
+		{"match s { }", "match needs at least one arm"},
+		{"match s { A 1 }", "expected next token to be =>, got INT instead"},
+	}
+
+	for _, tt := range tests {
+		lexer := lexer.NewLexer(tt.input)
+		parser := NewParser(lexer)
+		parser.ParseProgram()
+
+		errors := parser.Errors()
+
+		if len(errors) == 0 || errors[0] != tt.expected {
+			t.Errorf("wrong errors for %q. expected=%q, got=%q", tt.input, tt.expected, errors)
+		}
+	}
+}
+
+func TestTypeAnnotations(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let x: int = 5;", "let x: int = 5;"},
+		{"const names: [string] = [];", "const names: [string] = [];"},
+		{"let [a, b]: [int] = pair;", "let [a, b]: [int] = pair;"},
+		{"let ages: {string: int} = {};", "let ages: {string: int} = {};"},
+		{"fn(a: int, b) -> int { a }", "fn(a: int, b) -> int a"},
+		{"fn(a: bool = true, ...rest: [any]) { a }", "fn(a: bool = true, ...rest: [any]) a"},
+		{"let f: fn(int, [int]) -> fn() -> bool = g;", "let f: fn(int, [int]) -> fn() -> bool = g;"},
+		{"(a: int, b: int) => a + b", "fn(a: int, b: int) (a + b)"},
+		{"a -1", "(a - 1)"},
+	}
+
+	for _, tt := range tests {
+		parser := NewParser(lexer.NewLexer(tt.input))
+		program := parser.ParseProgram()
+		checkParserErrors(t, parser, tt.input)
+
+		if program.String() != tt.expected {
+			t.Errorf("wrong program for %q. expected=%q, got=%q", tt.input, tt.expected, program.String())
+		}
+	}
+}
+
+func TestTypeAnnotationErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let x: = 5;", "expected a type, got = instead"},
+		{"let x: [int = 5;", "expected next token to be ], got = instead"},
+		{"let x: {string} = 5;", "expected next token to be :, got } instead"},
+		{"let f: fn(int) = g;", "expected next token to be ->, got = instead"},
+		{"fn(a) -> 5 { a }", "expected a type, got INT instead"},
+		{"fn(...rest: [int] = []) { rest }", "rest parameter ...rest: [int] cannot have a default value"},
+	}
+
+	for _, tt := range tests {
+		parser := NewParser(lexer.NewLexer(tt.input))
+		parser.ParseProgram()
+
+		errors := parser.Errors()
+
+		if len(errors) == 0 || errors[0] != tt.expected {
+			t.Errorf("wrong errors for %q. expected=%q, got=%q", tt.input, tt.expected, errors)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package parser
+
+import (
+	"fmt"
+	"monkey/ast"
+	"monkey/token"
+)
+
+func (parser *Parser) parsePattern() ast.Pattern {
+	switch parser.currentToken.Type {
+	case token.IDENT:
+		return &ast.Identifier{Token: parser.currentToken, Value: parser.currentToken.Literal}
+	case token.LBRACKET:
+		return parser.parseArrayPattern()
+	case token.LBRACE:
+		return parser.parseHashPattern()
+	default:
+		message := fmt.Sprintf("expected binding pattern, got %s instead", parser.currentToken.Type)
+		parser.errors = append(parser.errors, message)
+
+		return nil
+	}
+}
+
+func (parser *Parser) parseArrayPattern() ast.Pattern {
+	pattern := &ast.ArrayPattern{Token: parser.currentToken}
+	pattern.Elements = []ast.Pattern{}
+
+	for !parser.peekTokenIs(token.RBRACKET) {
+		parser.nextToken()
+
+		if parser.currentTokenIs(token.ELLIPSIS) {
+			pattern.Rest = parser.parseRestElement("array")
+
+			if pattern.Rest == nil {
+				return nil
+			}
+
+			break
+		}
+
+		element := parser.parsePattern()
+
+		if element == nil {
+			return nil
+		}
+
+		pattern.Elements = append(pattern.Elements, element)
+
+		if !parser.peekTokenIs(token.RBRACKET) && !parser.expectPeek(token.COMMA) {
+			return nil
+		}
+	}
+
+	if !parser.expectPeek(token.RBRACKET) {
+		return nil
+	}
+
+	return pattern
+}
+
+func (parser *Parser) parseHashPattern() ast.Pattern {
+	pattern := &ast.HashPattern{Token: parser.currentToken}
+	pattern.Pairs = []*ast.HashPatternPair{}
+
+	for !parser.peekTokenIs(token.RBRACE) {
+		parser.nextToken()
+
+		if parser.currentTokenIs(token.ELLIPSIS) {
+			pattern.Rest = parser.parseRestElement("hash")
+
+			if pattern.Rest == nil {
+				return nil
+			}
+
+			break
+		}
+
+		if !parser.currentTokenIs(token.IDENT) {
+			message := fmt.Sprintf("expected field name in hash pattern, got %s instead", parser.currentToken.Type)
+			parser.errors = append(parser.errors, message)
+
+			return nil
+		}
+
+		key := &ast.Identifier{Token: parser.currentToken, Value: parser.currentToken.Literal}
+		pair := &ast.HashPatternPair{Key: key, Value: key}
+
+		if parser.peekTokenIs(token.COLON) {
+			parser.nextToken()
+			parser.nextToken()
+
+			pair.Value = parser.parsePattern()
+
+			if pair.Value == nil {
+				return nil
+			}
+		}
+
+		pattern.Pairs = append(pattern.Pairs, pair)
+
+		if !parser.peekTokenIs(token.RBRACE) && !parser.expectPeek(token.COMMA) {
+			return nil
+		}
+	}
+
+	if !parser.expectPeek(token.RBRACE) {
+		return nil
+	}
+
+	return pattern
+}
+
+// parseRestElement parses `...name` and makes sure it is the last element
+// of the enclosing pattern.
+func (parser *Parser) parseRestElement(kind string) *ast.Identifier {
+	if !parser.expectPeek(token.IDENT) {
+		return nil
+	}
+
+	rest := &ast.Identifier{Token: parser.currentToken, Value: parser.currentToken.Literal}
+
+	if parser.peekTokenIs(token.COMMA) {
+		message := fmt.Sprintf("rest element ...%s must be the last element of %s pattern", rest.Value, kind)
+		parser.errors = append(parser.errors, message)
+
+		return nil
+	}
+
+	return rest
+}
+
+// checkPatternShape reports a mismatch error when a destructuring pattern
+// is bound to a literal whose shape can never fit it.
+func (parser *Parser) checkPatternShape(pattern ast.Pattern, value ast.Expression) {
+	if value == nil {
+		return
+	}
+
+	switch pattern := pattern.(type) {
+	case *ast.ArrayPattern:
+		array, ok := value.(*ast.ArrayLiteral)
+
+		if !ok {
+			if isLiteral(value) {
+				parser.patternMismatchError(pattern, value, "value is not an array")
+			}
+
+			return
+		}
+
+		expected := len(pattern.Elements)
+		got := len(array.Elements)
+
+		if got < expected || (pattern.Rest == nil && got > expected) {
+			reason := fmt.Sprintf("expected %d elements, got %d", expected, got)
+
+			if pattern.Rest != nil {
+				reason = fmt.Sprintf("expected at least %d elements, got %d", expected, got)
+			}
+
+			parser.patternMismatchError(pattern, value, reason)
+
+			return
+		}
+
+		for i, element := range pattern.Elements {
+			parser.checkPatternShape(element, array.Elements[i])
+		}
+
+	case *ast.HashPattern:
+		if _, ok := value.(*ast.HashLiteral); !ok && isLiteral(value) {
+			parser.patternMismatchError(pattern, value, "value is not a hash")
+		}
+	}
+}
+
+func (parser *Parser) patternMismatchError(pattern ast.Pattern, value ast.Expression, reason string) {
+	message := fmt.Sprintf("destructuring mismatch: cannot bind %s to %s: %s", pattern.String(), value.String(), reason)
+	parser.errors = append(parser.errors, message)
+}
+
+func isLiteral(expression ast.Expression) bool {
+	switch expression.(type) {
+	case *ast.IntegerLiteral, *ast.StringLiteral, *ast.Boolean, *ast.ArrayLiteral, *ast.HashLiteral, *ast.FunctionLiteral:
+		return true
+	default:
+		return false
+	}
+}
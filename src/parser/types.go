@@ -0,0 +1,110 @@
+package parser
+
+import (
+	"fmt"
+	"monkey/ast"
+	"monkey/token"
+)
+
+// parseTypeAnnotation parses the `: type` following a binding, if there is
+// one. ok is false when the annotation is malformed.
+func (parser *Parser) parseTypeAnnotation() (annotation ast.TypeExpression, ok bool) {
+	if !parser.peekTokenIs(token.COLON) {
+		return nil, true
+	}
+
+	parser.nextToken()
+	parser.nextToken()
+
+	annotation = parser.parseType()
+
+	return annotation, annotation != nil
+}
+
+func (parser *Parser) parseType() ast.TypeExpression {
+	switch parser.currentToken.Type {
+	case token.IDENT:
+		return &ast.TypeName{Token: parser.currentToken, Name: parser.currentToken.Literal}
+	case token.LBRACKET:
+		return parser.parseArrayType()
+	case token.LBRACE:
+		return parser.parseHashType()
+	case token.FUNCTION:
+		return parser.parseFunctionType()
+	default:
+		message := fmt.Sprintf("expected a type, got %s instead", parser.currentToken.Type)
+		parser.errors = append(parser.errors, message)
+
+		return nil
+	}
+}
+
+func (parser *Parser) parseArrayType() ast.TypeExpression {
+	array := &ast.ArrayType{Token: parser.currentToken}
+
+	parser.nextToken()
+
+	if array.Element = parser.parseType(); array.Element == nil {
+		return nil
+	}
+
+	if !parser.expectPeek(token.RBRACKET) {
+		return nil
+	}
+
+	return array
+}
+
+func (parser *Parser) parseHashType() ast.TypeExpression {
+	hash := &ast.HashType{Token: parser.currentToken}
+
+	parser.nextToken()
+
+	if hash.Key = parser.parseType(); hash.Key == nil || !parser.expectPeek(token.COLON) {
+		return nil
+	}
+
+	parser.nextToken()
+
+	if hash.Value = parser.parseType(); hash.Value == nil || !parser.expectPeek(token.RBRACE) {
+		return nil
+	}
+
+	return hash
+}
+
+func (parser *Parser) parseFunctionType() ast.TypeExpression {
+	function := &ast.FunctionType{Token: parser.currentToken, Parameters: []ast.TypeExpression{}}
+
+	if !parser.expectPeek(token.LPAREN) {
+		return nil
+	}
+
+	for !parser.peekTokenIs(token.RPAREN) {
+		parser.nextToken()
+
+		parameter := parser.parseType()
+
+		if parameter == nil {
+			return nil
+		}
+
+		function.Parameters = append(function.Parameters, parameter)
+
+		if !parser.peekTokenIs(token.RPAREN) && !parser.expectPeek(token.COMMA) {
+			return nil
+		}
+	}
+
+	if !parser.expectPeek(token.RPAREN) || !parser.expectPeek(token.THIN_ARROW) {
+		return nil
+	}
+
+	parser.nextToken()
+
+	if function.Result = parser.parseType(); function.Result == nil {
+		return nil
+	}
+
+	return function
+}
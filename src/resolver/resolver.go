@@ -0,0 +1,335 @@
+// Package resolver binds the identifiers of a program to the declarations
+// they refer to.
+//
+// Every scope is searched for its declarations before its statements are
+// resolved, so a function may call a function declared after it. A name
+// used before its let statement in the same function is an error, while
+// uses from inside a nested function are not, since the function may only
+// run once the name is bound.
+package resolver
+
+import (
+	"monkey/ast"
+	"sort"
+)
+
+// Resolution is the result of resolving a program.
+type Resolution struct {
+	Program      *Scope                           // the scope of the top-level statements
+	Uses         map[*ast.Identifier]*Declaration // every identifier referring to a declaration
+	Declarations map[*ast.Identifier]*Declaration // every identifier that declares a name
+	Scopes       map[ast.Node]*Scope              // the scope every scope-opening node opens
+	Errors       []Error                          // in source order
+}
+
+// Resolve resolves every identifier in program. Names in builtins are
+// predeclared in a scope enclosing the program.
+func Resolve(program *ast.Program, builtins ...string) *Resolution {
+	universe := newScope(nil, nil)
+
+	for _, name := range builtins {
+		universe.names[name] = &Declaration{Kind: Builtin, Scope: universe, Uses: []*ast.Identifier{}, defined: true}
+	}
+
+	resolver := &resolver{
+		resolution: &Resolution{
+			Uses:         map[*ast.Identifier]*Declaration{},
+			Declarations: map[*ast.Identifier]*Declaration{},
+			Scopes:       map[ast.Node]*Scope{},
+			Errors:       []Error{},
+		},
+		scope: universe,
+	}
+
+	resolver.resolution.Program = resolver.push(program)
+	resolver.statements(program.Statemens)
+
+	errors := resolver.resolution.Errors
+	sort.SliceStable(errors, func(i, j int) bool {
+		if errors[i].Line != errors[j].Line {
+			return errors[i].Line < errors[j].Line
+		}
+
+		return errors[i].Column < errors[j].Column
+	})
+
+	return resolver.resolution
+}
+
+type resolver struct {
+	resolution *Resolution
+	scope      *Scope
+}
+
+func (resolver *resolver) push(node ast.Node) *Scope {
+	resolver.scope = newScope(node, resolver.scope)
+	resolver.resolution.Scopes[node] = resolver.scope
+
+	return resolver.scope
+}
+
+func (resolver *resolver) pop() {
+	resolver.scope = resolver.scope.Parent
+}
+
+func (resolver *resolver) errorf(name *ast.Identifier, format string, args ...interface{}) {
+	resolver.resolution.Errors = append(resolver.resolution.Errors, newError(name, format, args...))
+}
+
+// Visit resolves the identifiers in expressions and leaves everything that
+// declares names or opens a scope to the methods below.
+func (resolver *resolver) Visit(node ast.Node) ast.Visitor {
+	switch node := node.(type) {
+	case *ast.Identifier:
+		resolver.use(node)
+
+	case *ast.BlockStatement:
+		resolver.push(node)
+		resolver.statements(node.Statements)
+		resolver.pop()
+
+	case *ast.LetStatement:
+		resolver.walk(node.Value)
+		resolver.define(ast.PatternNames(node.Target())...)
+
+	case *ast.ImportStatement:
+		if node.Alias != nil {
+			resolver.define(node.Alias)
+		}
+
+	case *ast.StructStatement:
+		resolver.define(node.Name)
+
+	case *ast.EnumStatement:
+		resolver.define(node.Name)
+
+		for _, variant := range node.Variants {
+			resolver.define(variant.Name)
+		}
+
+	case *ast.FunctionLiteral:
+		resolver.function(node)
+
+	case *ast.TryStatement:
+		resolver.try(node)
+
+	case *ast.SelectCase:
+		resolver.walk(node.Operation)
+		resolver.push(node)
+
+		if node.Binding != nil {
+			resolver.define(resolver.declare(node.Binding, Variable))
+		}
+
+		resolver.statements(node.Body.Statements)
+		resolver.pop()
+
+	case *ast.MatchArm:
+		resolver.push(node)
+		resolver.pattern(node.Pattern)
+		resolver.statements(node.Body.Statements)
+		resolver.pop()
+
+	case *ast.MemberExpression:
+		resolver.walk(node.Object)
+
+	case *ast.NamedArgument:
+		resolver.walk(node.Value)
+
+	default:
+		return resolver
+	}
+
+	return nil
+}
+
+func (resolver *resolver) walk(node ast.Node) {
+	if node != nil {
+		ast.Walk(resolver, node)
+	}
+}
+
+// statements declares the names bound by the statements of a scope and
+// then resolves the statements in order.
+func (resolver *resolver) statements(statements []ast.Statement) {
+	for _, statement := range statements {
+		switch statement := statement.(type) {
+		case *ast.LetStatement:
+			resolver.declareAll(ast.PatternNames(statement.Target()), letKind(statement))
+
+		case *ast.ExportStatement:
+			resolver.declareAll(ast.PatternNames(statement.Declaration.Target()), letKind(statement.Declaration))
+
+		case *ast.ImportStatement:
+			if statement.Alias != nil {
+				resolver.declare(statement.Alias, Import)
+			}
+
+		case *ast.StructStatement:
+			resolver.declare(statement.Name, Struct)
+
+		case *ast.EnumStatement:
+			resolver.declare(statement.Name, Enum)
+
+			for _, variant := range statement.Variants {
+				resolver.declare(variant.Name, Variant)
+			}
+		}
+	}
+
+	for _, statement := range statements {
+		resolver.walk(statement)
+	}
+}
+
+func letKind(statement *ast.LetStatement) Kind {
+	if statement.IsConst() {
+		return Constant
+	}
+
+	return Variable
+}
+
+func (resolver *resolver) declareAll(names []*ast.Identifier, kind Kind) {
+	for _, name := range names {
+		resolver.declare(name, kind)
+	}
+}
+
+// declare binds name in the current scope, reporting a duplicate if the
+// scope already binds it. Variants of different enums may share a name;
+// match patterns tell them apart by their enum. The blank identifier _
+// binds nothing.
+func (resolver *resolver) declare(name *ast.Identifier, kind Kind) *ast.Identifier {
+	if name.Value == "_" {
+		return name
+	}
+
+	scope := resolver.scope
+	declaration := &Declaration{Name: name, Kind: kind, Scope: scope, Uses: []*ast.Identifier{}}
+
+	scope.Declarations = append(scope.Declarations, declaration)
+	resolver.resolution.Declarations[name] = declaration
+
+	if existing := scope.names[name.Value]; existing != nil {
+		if kind != Variant || existing.Kind != Variant {
+			resolver.errorf(name, "%s is already declared at %d:%d", name.Value, existing.Name.Token.Line, existing.Name.Token.Column)
+		}
+
+		return name
+	}
+
+	declaration.Shadows = scope.Parent.Lookup(name.Value)
+	scope.names[name.Value] = declaration
+
+	return name
+}
+
+// define marks the declarations of names as reached.
+func (resolver *resolver) define(names ...*ast.Identifier) {
+	for _, name := range names {
+		if declaration, ok := resolver.resolution.Declarations[name]; ok {
+			declaration.defined = true
+		}
+	}
+}
+
+func (resolver *resolver) use(name *ast.Identifier) {
+	if name.Value == "_" {
+		resolver.errorf(name, "cannot use _ as a value")
+		return
+	}
+
+	declaration := resolver.scope.Lookup(name.Value)
+
+	if declaration == nil {
+		resolver.errorf(name, "%s is not defined", name.Value)
+		return
+	}
+
+	declaration.Uses = append(declaration.Uses, name)
+	resolver.resolution.Uses[name] = declaration
+
+	if !declaration.defined && declaration.Scope.function == resolver.scope.function {
+		resolver.errorf(name, "%s is used before its declaration at %d:%d",
+			name.Value, declaration.Name.Token.Line, declaration.Name.Token.Column)
+	}
+}
+
+// function resolves a function in a scope of its own, shared by the
+// parameters and the body. A default value sees the parameters before it.
+func (resolver *resolver) function(function *ast.FunctionLiteral) {
+	resolver.push(function)
+
+	for _, parameter := range function.Parameters {
+		resolver.walk(parameter.Default)
+
+		for _, name := range ast.PatternNames(parameter.Pattern) {
+			resolver.define(resolver.declare(name, Parameter))
+		}
+	}
+
+	resolver.statements(function.Body.Statements)
+	resolver.pop()
+}
+
+// try resolves the catch clause in a scope holding the catch parameter.
+func (resolver *resolver) try(try *ast.TryStatement) {
+	resolver.walk(try.Block)
+
+	if try.Catch != nil {
+		resolver.push(try.Catch)
+
+		if try.CatchParameter != nil {
+			resolver.define(resolver.declare(try.CatchParameter, Catch))
+		}
+
+		resolver.statements(try.Catch.Statements)
+		resolver.pop()
+	}
+
+	if try.Finally != nil {
+		resolver.walk(try.Finally)
+	}
+}
+
+// pattern declares the names a match arm pattern binds and resolves the
+// enums, variants and literals it refers to.
+func (resolver *resolver) pattern(pattern ast.Pattern) {
+	switch pattern := pattern.(type) {
+	case *ast.Identifier:
+		resolver.define(resolver.declare(pattern, Variable))
+
+	case *ast.LiteralPattern:
+		resolver.walk(pattern.Value)
+
+	case *ast.VariantPattern:
+		if pattern.Enum != nil {
+			resolver.use(pattern.Enum)
+		} else {
+			resolver.use(pattern.Variant)
+		}
+
+		for _, binding := range pattern.Bindings {
+			resolver.pattern(binding)
+		}
+
+	case *ast.ArrayPattern:
+		for _, element := range pattern.Elements {
+			resolver.pattern(element)
+		}
+
+		if pattern.Rest != nil {
+			resolver.define(resolver.declare(pattern.Rest, Variable))
+		}
+
+	case *ast.HashPattern:
+		for _, pair := range pattern.Pairs {
+			resolver.pattern(pair.Value)
+		}
+
+		if pattern.Rest != nil {
+			resolver.define(resolver.declare(pattern.Rest, Variable))
+		}
+	}
+}
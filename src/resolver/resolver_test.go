@@ -0,0 +1,160 @@
+package resolver
+
+import (
+	"fmt"
+	"monkey/ast"
+	"monkey/lexer"
+	"monkey/parser"
+	"testing"
+)
+
+func resolve(t *testing.T, input string, builtins ...string) (*ast.Program, *Resolution) {
+	t.Helper()
+
+	newParser := parser.NewParser(lexer.NewLexer(input))
+	program := newParser.ParseProgram()
+
+	if errors := newParser.Errors(); len(errors) > 0 {
+		t.Fatalf("parser errors for %q: %v", input, errors)
+	}
+
+	return program, Resolve(program, builtins...)
+}
+
+// bindings describes every resolved use as name@line:column -> line:column
+// of the declaration, in source order.
+func bindings(program *ast.Program, resolution *Resolution) []string {
+	result := []string{}
+
+	ast.Inspect(program, func(node ast.Node) bool {
+		if name, ok := node.(*ast.Identifier); ok {
+			if declaration, ok := resolution.Uses[name]; ok {
+				target := "builtin"
+
+				if declaration.Name != nil {
+					target = fmt.Sprintf("%d:%d", declaration.Name.Token.Line, declaration.Name.Token.Column)
+				}
+
+				result = append(result, fmt.Sprintf("%s@%d:%d -> %s", name.Value, name.Token.Line, name.Token.Column, target))
+			}
+		}
+
+		return true
+	})
+
+	return result
+}
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"let x = 1; x;", []string{"x@1:12 -> 1:5"}},
+		{"let x = 1; let f = fn(x) { x }; x;", []string{"x@1:28 -> 1:23", "x@1:33 -> 1:5"}},
+		// a function may refer to itself and to later declarations
+		{"let f = fn() { g() }; let g = fn() { f() };", []string{"g@1:16 -> 1:27", "f@1:38 -> 1:5"}},
+		{"let [a, ...b] = c(); let {d, e: [f]} = a;", []string{"c@1:17 -> builtin", "a@1:40 -> 1:6"}},
+		{"fn(a, b = a) { b };", []string{"a@1:11 -> 1:4", "b@1:16 -> 1:7"}},
+		{"let x = 1; try { x } catch (x) { x } finally { x }",
+			[]string{"x@1:18 -> 1:5", "x@1:34 -> 1:29", "x@1:48 -> 1:5"}},
+		{"select { case v = recv(ch) { v } }", []string{"recv@1:19 -> builtin", "ch@1:24 -> builtin", "v@1:30 -> 1:15"}},
+		{"enum S { A(x), B } match s { A(v) => v, S.B => 0, other => other };",
+			[]string{"s@1:26 -> builtin", "A@1:30 -> 1:10", "v@1:38 -> 1:32", "S@1:41 -> 1:6", "other@1:60 -> 1:51"}},
+		{"import \"lib\" as lib; lib.upper(name: lib);", []string{"lib@1:22 -> 1:17", "lib@1:38 -> 1:17"}},
+		{"struct P { x, y } P;", []string{"P@1:19 -> 1:8"}},
+		{"let h = {k: k}; h.k;", []string{"k@1:10 -> builtin", "k@1:13 -> builtin", "h@1:17 -> 1:5"}},
+		{"export let x = 1; try { let x = 2; x } finally { x } x;",
+			[]string{"x@1:36 -> 1:29", "x@1:50 -> 1:12", "x@1:54 -> 1:12"}},
+	}
+
+	for _, tt := range tests {
+		program, resolution := resolve(t, tt.input, "c", "recv", "ch", "s", "k")
+
+		if len(resolution.Errors) > 0 {
+			t.Errorf("unexpected errors for %q: %v", tt.input, resolution.Errors)
+		}
+
+		got := bindings(program, resolution)
+
+		if fmt.Sprint(got) != fmt.Sprint(tt.expected) {
+			t.Errorf("wrong bindings for %q.\nexpected=%v\ngot=     %v", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestResolveErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"x;", []string{"1:1: x is not defined"}},
+		{"let f = fn(a) { b };", []string{"1:17: b is not defined"}},
+		{"let x = 1; let x = 2;", []string{"1:16: x is already declared at 1:5"}},
+		{"fn(a, [b]) { let b = 1; let a = 2; };", []string{"1:18: b is already declared at 1:8", "1:29: a is already declared at 1:4"}},
+		{"x; let x = 1;", []string{"1:1: x is used before its declaration at 1:8"}},
+		{"let x = x;", []string{"1:9: x is used before its declaration at 1:5"}},
+		{"let x = 1; fn() { x; let x = 2; };", []string{"1:19: x is used before its declaration at 1:26"}},
+		{"fn(a = b, b = 1) { };", []string{"1:8: b is not defined"}},
+		{"try { let y = 1; } finally { y } y;", []string{"1:30: y is not defined", "1:34: y is not defined"}},
+		{"let _ = 1; _;", []string{"1:12: cannot use _ as a value"}},
+		{"enum A { X } enum B { X, Y } struct A { a }", []string{"1:37: A is already declared at 1:6"}},
+		{"match v { Unknown => 1 };", []string{"1:7: v is not defined", "1:11: Unknown is not defined"}},
+		{"let x: int = 1; let f = fn(a: int) -> string { a }; f(x);", []string{}},
+	}
+
+	for _, tt := range tests {
+		_, resolution := resolve(t, tt.input)
+
+		got := []string{}
+		for _, err := range resolution.Errors {
+			got = append(got, err.Error())
+		}
+
+		if fmt.Sprint(got) != fmt.Sprint(tt.expected) {
+			t.Errorf("wrong errors for %q.\nexpected=%v\ngot=     %v", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestScopes(t *testing.T) {
+	program, resolution := resolve(t, "let g = 1; let f = fn(a) { let b = a; let g = b; };", "len")
+
+	if resolution.Program != resolution.Scopes[program] {
+		t.Fatalf("expected the program scope to be the scope of the program")
+	}
+
+	function := program.Statemens[1].(*ast.LetStatement).Value.(*ast.FunctionLiteral)
+	scope := resolution.Scopes[function]
+
+	if scope == nil || scope.Parent != resolution.Program || len(resolution.Program.Children) != 1 {
+		t.Fatalf("expected the function scope to be the only child of the program scope")
+	}
+
+	names := []string{}
+	for _, declaration := range scope.Declarations {
+		names = append(names, declaration.Kind.String()+" "+declaration.Name.Value)
+	}
+
+	if fmt.Sprint(names) != "[parameter a variable b variable g]" {
+		t.Errorf("wrong declarations in function scope. got=%v", names)
+	}
+
+	inner := scope.Local("g")
+
+	if inner == nil || inner.Shadows != resolution.Program.Local("g") {
+		t.Errorf("expected inner g to shadow the outer g")
+	}
+
+	if scope.Local("len") != nil || scope.Lookup("len") == nil || scope.Lookup("len").Kind != Builtin {
+		t.Errorf("expected len to be found as a builtin only by Lookup")
+	}
+
+	if uses := scope.Local("a").Uses; len(uses) != 1 || uses[0].Token.Column != 36 {
+		t.Errorf("wrong uses of a. got=%v", uses)
+	}
+
+	if uses := inner.Uses; len(uses) != 0 {
+		t.Errorf("expected inner g to be unused. got=%v", uses)
+	}
+}
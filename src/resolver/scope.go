@@ -0,0 +1,110 @@
+package resolver
+
+import (
+	"fmt"
+	"monkey/ast"
+)
+
+type Kind int
+
+const (
+	Builtin   Kind = iota // predeclared by the host, has no Name
+	Variable              // bound by let, or by a match arm or select case
+	Constant              // bound by const
+	Parameter             // bound by a function parameter
+	Catch                 // the parameter of a catch clause
+	Import                // the alias of an import
+	Struct
+	Enum
+	Variant // a variant of an enum, declared next to the enum
+)
+
+var kindNames = map[Kind]string{
+	Builtin:   "builtin",
+	Variable:  "variable",
+	Constant:  "constant",
+	Parameter: "parameter",
+	Catch:     "catch parameter",
+	Import:    "import",
+	Struct:    "struct",
+	Enum:      "enum",
+	Variant:   "variant",
+}
+
+func (kind Kind) String() string {
+	return kindNames[kind]
+}
+
+// Declaration is a name bound in a scope together with every identifier
+// that refers to it.
+type Declaration struct {
+	Name    *ast.Identifier // nil for builtins
+	Kind    Kind
+	Scope   *Scope
+	Uses    []*ast.Identifier // in source order
+	Shadows *Declaration      // the declaration of the same name in an enclosing scope, if any
+
+	defined bool // the declaration has been reached; see Resolve
+}
+
+// Scope is a region of the program in which names are bound. The program,
+// every function, catch clause, select case and match arm, and every other
+// block open a scope. The bindings of a construct and the statements of its
+// block share one scope, so a function body cannot redeclare a parameter.
+type Scope struct {
+	Node         ast.Node // the node that opens the scope; nil for the builtins
+	Parent       *Scope
+	Children     []*Scope
+	Declarations []*Declaration // in source order, including duplicates
+
+	names    map[string]*Declaration
+	function *Scope // the innermost enclosing function scope, or the program scope
+}
+
+func newScope(node ast.Node, parent *Scope) *Scope {
+	scope := &Scope{Node: node, Parent: parent, Children: []*Scope{}, Declarations: []*Declaration{}, names: map[string]*Declaration{}}
+
+	if parent != nil {
+		parent.Children = append(parent.Children, scope)
+		scope.function = parent.function
+	}
+
+	if _, ok := node.(*ast.FunctionLiteral); ok || parent == nil || parent.Parent == nil {
+		scope.function = scope
+	}
+
+	return scope
+}
+
+// Lookup finds the declaration name refers to in this scope or the
+// innermost enclosing scope that declares it, or returns nil.
+func (scope *Scope) Lookup(name string) *Declaration {
+	for ; scope != nil; scope = scope.Parent {
+		if declaration, ok := scope.names[name]; ok {
+			return declaration
+		}
+	}
+
+	return nil
+}
+
+// Local returns the declaration of name in this scope itself, or nil.
+func (scope *Scope) Local(name string) *Declaration {
+	return scope.names[name]
+}
+
+// Error is a problem found while resolving names, at the position of the
+// identifier it refers to.
+type Error struct {
+	Line    int
+	Column  int
+	Message string
+}
+
+func (err Error) Error() string {
+	return fmt.Sprintf("%d:%d: %s", err.Line, err.Column, err.Message)
+}
+
+func newError(name *ast.Identifier, format string, args ...interface{}) Error {
+	return Error{Line: name.Token.Line, Column: name.Token.Column, Message: fmt.Sprintf(format, args...)}
+}
@@ -5,10 +5,19 @@ type TokenType string
 const (
 	ILLEGAL = "ILLEGAL"
 	EOF     = "EOF"
+	COMMENT = "COMMENT" // a // line comment; collected by the lexer, never returned by NextToken
 
 	// Identifiers + literals
-	IDENT = "IDENT" // add, foobar, x, y, ...
-	INT   = "INT"   // 1343456
+	IDENT  = "IDENT"  // add, foobar, x, y, ...
+	INT    = "INT"    // 1343456
+	STRING = "STRING" // "foo bar"
+
+	// Template literals: `Hello ${name}!`
+	TEMPLATE_START      = "TEMPLATE_START"  // opening backtick
+	TEMPLATE_STRING     = "TEMPLATE_STRING" // literal text between embedded expressions
+	TEMPLATE_EXPR_START = "${"
+	TEMPLATE_EXPR_END   = "TEMPLATE_EXPR_END" // the } closing an embedded expression
+	TEMPLATE_END        = "TEMPLATE_END"      // closing backtick
 
 	// Operators
 	ASSIGN   = "="
@@ -24,38 +33,84 @@ const (
 	EQ     = "=="
 	NOT_EQ = "!="
 
+	ARROW      = "=>"
+	THIN_ARROW = "->" // before the result type of a function
+	PIPE       = "|>"
+
 	// Delimiters
 	COMMA     = ","
 	SEMICOLON = ";"
+	COLON     = ":"
+	DOT       = "."
+	ELLIPSIS  = "..."
 
 	LPAREN = "("
 	RPAREN = ")"
 	LBRACE = "{"
 	RBRACE = "}"
 
+	LBRACKET = "["
+	RBRACKET = "]"
+
 	// Keywords
 	FUNCTION = "FUNCTION"
 	LET      = "LET"
+	CONST    = "CONST"
 	TRUE     = "TRUE"
 	FALSE    = "FALSE"
 	IF       = "IF"
 	ELSE     = "ELSE"
 	RETURN   = "RETURN"
+	IMPORT   = "IMPORT"
+	EXPORT   = "EXPORT"
+	AS       = "AS"
+	THROW    = "THROW"
+	TRY      = "TRY"
+	CATCH    = "CATCH"
+	FINALLY  = "FINALLY"
+	DEFER    = "DEFER"
+	YIELD    = "YIELD"
+	SPAWN    = "SPAWN"
+	SELECT   = "SELECT"
+	CASE     = "CASE"
+	DEFAULT  = "DEFAULT"
+	STRUCT   = "STRUCT"
+	ENUM     = "ENUM"
+	MATCH    = "MATCH"
 )
 
 type Token struct {
 	Type    TokenType
 	Literal string
+	Line    int // 1-based line of the first character
+	Column  int // 1-based column of the first character
 }
 
 var keywords = map[string]TokenType{
-	"fn":     FUNCTION,
-	"let":    LET,
-	"true":   TRUE,
-	"false":  FALSE,
-	"if":     IF,
-	"else":   ELSE,
-	"return": RETURN,
+	"fn":      FUNCTION,
+	"let":     LET,
+	"const":   CONST,
+	"true":    TRUE,
+	"false":   FALSE,
+	"if":      IF,
+	"else":    ELSE,
+	"return":  RETURN,
+	"import":  IMPORT,
+	"export":  EXPORT,
+	"as":      AS,
+	"throw":   THROW,
+	"try":     TRY,
+	"catch":   CATCH,
+	"finally": FINALLY,
+	"defer":   DEFER,
+	"yield":   YIELD,
+	"spawn":   SPAWN,
+	"select":  SELECT,
+	"case":    CASE,
+	"default": DEFAULT,
+	"struct":  STRUCT,
+	"enum":    ENUM,
+	"match":   MATCH,
 }
 
 func LookupIdent(ident string) TokenType {